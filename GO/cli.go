@@ -0,0 +1,708 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//**** SOUS-COMMANDES DE LA LIGNE DE COMMANDE ****//
+
+// Codes de sortie du programme
+const (
+	exitOK      = 0 //l'action a réussi
+	exitFailure = 1 //l'action a échoué (destination injoignable, Hello sans réponse...)
+	exitUsage   = 2 //ligne de commande ou topologie invalide
+)
+
+// Sous-commande : son nom, sa description et la fonction qui l'exécute
+type command struct {
+	Name        string
+	Description string
+	Run         func(args []string) int
+}
+
+var commands []command //rempli par init, car les sous-commandes affichent elles-mêmes la liste
+
+func init() {
+	commands = []command{
+		{"generate", "génère un graphe (-n, -i, -shape, -weights, -seed) et l'écrit au format JSON (-o)", runGenerate},
+		{"run", "démarre le réseau et envoie une série de Hello depuis chaque routeur (-rounds)", runRun},
+		{"route", "affiche la route entre deux routeurs (-src, -dst)", runRoute},
+		{"paths", "liste les -k plus courts chemins et les chemins disjoints entre deux routeurs (-src, -dst)", runPaths},
+		{"ping", "envoie des Hello d'un routeur à un autre et mesure le temps de réponse (-src, -dst, -count)", runPing},
+		{"fail-link", "supprime un lien (-a, -b), attend la convergence et affiche les routes modifiées", runFailLink},
+		{"add-link", "ajoute un lien (-a, -b) de poids -weight et affiche les routes modifiées", runAddLink},
+		{"change-cost", "change le poids du lien -a - -b (-weight) et affiche les routes modifiées", runChangeCost},
+		{"fail-router", "met un routeur en panne (-r), affiche les routes modifiées, puis le redémarre avec -recover", runFailRouter},
+		{"lfa", "affiche la part des routes protégées par un next hop de secours et les secours d'un routeur (-r)", runLFA},
+		{"scenario", "joue un fichier de scénario (-file) et affiche le bilan de chaque phase", runScenarioCommand},
+		{"interactive", "menu interactif (mode par défaut sans sous-commande)", runInteractive},
+	}
+}
+
+func runCommand(args []string) int {
+	/*
+		runCommand exécute la sous-commande donnée par les arguments restants après les options globales.
+
+		Paramètres :
+			- args : les arguments, dont le premier est le nom de la sous-commande
+
+		Sans argument, le menu interactif est lancé comme avant l'ajout des sous-commandes.
+
+		Retourne :
+			- Le code de sortie du programme (exitOK, exitFailure ou exitUsage)
+	*/
+	if len(args) == 0 {
+		return runInteractive(nil)
+	}
+	for _, cmd := range commands {
+		if cmd.Name == args[0] {
+			return cmd.Run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Sous-commande inconnue : %s\n", args[0])
+	printCommands()
+	return exitUsage
+}
+
+func printCommands() {
+	/*
+		printCommands affiche la liste des sous-commandes sur la sortie d'erreur.
+
+		La fonction ne retourne rien.
+	*/
+	fmt.Fprintln(os.Stderr, "Utilisation : elp [options] [sous-commande] [options de la sous-commande]")
+	fmt.Fprintln(os.Stderr, "Sous-commandes :")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.Name, cmd.Description)
+	}
+	fmt.Fprintln(os.Stderr, "Options globales (acceptées aussi après la sous-commande) :")
+	flag.PrintDefaults()
+}
+
+func newCommandFlags(name string) *flag.FlagSet {
+	/*
+		newCommandFlags crée les options d'une sous-commande. Les options globales (-topology, -n,
+		-seed, -protocol...) y sont ajoutées, pour qu'elles puissent aussi être données après
+		le nom de la sous-commande.
+
+		Paramètres :
+			- name : le nom de la sous-commande
+
+		Retourne :
+			- L'ensemble d'options, à compléter avec les options propres à la sous-commande
+	*/
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	return fs
+}
+
+func parseCommandFlags(fs *flag.FlagSet, args []string) bool {
+	/*
+		parseCommandFlags lit les options d'une sous-commande.
+
+		Paramètres :
+			- fs : les options de la sous-commande
+			- args : les arguments de la sous-commande
+
+		Retourne :
+			- false si les options sont invalides ou si des arguments en trop ont été donnés
+	*/
+	if err := fs.Parse(args); err != nil {
+		return false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Arguments inattendus : %s\n", strings.Join(fs.Args(), " "))
+		return false
+	}
+	return true
+}
+
+func startCommandNetwork(prompt bool) (*Graph, int) {
+	/*
+		startCommandNetwork crée le graphe et démarre le routage pour une sous-commande.
+
+		Paramètres :
+			- prompt : true pour autoriser les saisies au clavier (menu interactif)
+
+		Retourne :
+			- Le graphe démarré, nil en cas d'erreur
+			- Le code de sortie à utiliser en cas d'erreur
+	*/
+	graph, err := buildGraph(prompt)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return nil, exitUsage
+	}
+	if err := startNetwork(&graph); err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return nil, exitUsage
+	}
+	return &graph, exitOK
+}
+
+func lookupRouter(g *Graph, name string) (*Node, error) {
+	/*
+		lookupRouter cherche un routeur à partir de son nom ("R3") ou de son numéro ("3").
+
+		Paramètres :
+			- g : le graphe de la simulation
+			- name : le nom ou le numéro du routeur
+
+		Retourne :
+			- Le routeur
+			- Une erreur si le routeur n'existe pas
+	*/
+	if name == "" {
+		return nil, errors.New("routeur non indiqué")
+	}
+	if num, err := strconv.Atoi(name); err == nil {
+		name = fmt.Sprintf("R%d", num)
+	}
+	if node := findNode(g, name); node != nil {
+		return node, nil
+	}
+	return nil, fmt.Errorf("routeur inconnu : %s", name)
+}
+
+func lookupRouters(g *Graph, names ...string) ([]*Node, bool) {
+	/*
+		lookupRouters cherche plusieurs routeurs et affiche une erreur pour ceux qui n'existent pas.
+
+		Paramètres :
+			- g : le graphe de la simulation
+			- names : les noms ou numéros des routeurs
+
+		Retourne :
+			- Les routeurs, dans l'ordre des noms
+			- false si l'un des routeurs n'existe pas
+	*/
+	nodes := make([]*Node, len(names))
+	for i, name := range names {
+		node, err := lookupRouter(g, name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Erreur :", err)
+			return nil, false
+		}
+		nodes[i] = node
+	}
+	return nodes, true
+}
+
+func runGenerate(args []string) int {
+	/*
+		runGenerate génère un graphe (aléatoire ou de la forme -shape) et l'écrit au format de
+		topologie JSON, qui peut ensuite être relu avec -topology.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- Le code de sortie du programme
+	*/
+	fs := newCommandFlags("generate")
+	output := fs.String("o", "", "fichier JSON à créer (sortie standard par défaut)")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	if *topologyPath != "" {
+		fmt.Fprintln(os.Stderr, "Erreur : generate crée un graphe, -topology ne peut pas être utilisée")
+		return exitUsage
+	}
+	graph, err := buildGraph(false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitUsage
+	}
+	// La graine enregistrée est celle qui a généré le graphe : relancer avec elle redonne le même fichier
+	data, err := json.MarshalIndent(graphToTopology(&graph, max(maxEdges, maxDegree(&graph)), simulationSeed), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitFailure
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		os.Stdout.Write(data)
+		return exitOK
+	}
+	if err := os.WriteFile(*output, data, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitFailure
+	}
+	fmt.Printf("Topologie de %d routeurs écrite dans %s.\n", len(graph.Nodes), *output)
+	return exitOK
+}
+
+func runRun(args []string) int {
+	/*
+		runRun démarre le réseau et envoie -rounds séries de Hello, chaque routeur envoyant un Hello
+		à une destination aléatoire à chaque série.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si tous les Hello ont reçu leur Hello Ack, exitFailure sinon
+	*/
+	fs := newCommandFlags("run")
+	rounds := fs.Int("rounds", 1, "nombre de séries de Hello")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	lost := 0
+	var all []*helloRequest
+	for i := 0; i < *rounds; i++ {
+		requests := randomHelloRequests(graph)
+		lost += len(helloRound(requests, *helloTimeout))
+		all = append(all, requests...)
+	}
+	sent := len(all)
+	fmt.Printf("\n%d Hello envoyés, %d Hello Ack reçus, %d perdus.\n", sent, sent-lost, lost)
+	printHelloLatency(all)
+	if lost > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runRoute(args []string) int {
+	/*
+		runRoute démarre le réseau et affiche la route entre deux routeurs.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si la destination est joignable, exitFailure sinon
+	*/
+	fs := newCommandFlags("route")
+	src := fs.String("src", "", "routeur source (nom ou numéro)")
+	dst := fs.String("dst", "", "routeur destination (nom ou numéro)")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	nodes, ok := lookupRouters(graph, *src, *dst)
+	if !ok {
+		return exitUsage
+	}
+	printRoute(nodes[0], nodes[1])
+	if !nodes[0].Route(nodes[1].Name).Reachable() {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runPaths(args []string) int {
+	/*
+		runPaths crée le graphe, sans démarrer le réseau, et affiche les plus courts chemins et les
+		chemins disjoints entre deux routeurs (voir printPathDiversity).
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si la paire résiste à la panne de n'importe quel lien ou routeur intermédiaire,
+			  exitFailure sinon
+	*/
+	fs := newCommandFlags("paths")
+	src := fs.String("src", "", "routeur source (nom ou numéro)")
+	dst := fs.String("dst", "", "routeur destination (nom ou numéro)")
+	k := fs.Int("k", 3, "nombre de plus courts chemins à afficher")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	if *k < 1 {
+		fmt.Fprintln(os.Stderr, "Erreur : -k doit être au moins 1")
+		return exitUsage
+	}
+	graph, err := buildGraph(false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitUsage
+	}
+	nodes, ok := lookupRouters(&graph, *src, *dst)
+	if !ok {
+		return exitUsage
+	}
+	if nodes[0] == nodes[1] {
+		fmt.Fprintln(os.Stderr, "Erreur : la source et la destination doivent être différentes")
+		return exitUsage
+	}
+	if !printPathDiversity(&graph, nodes[0], nodes[1], *k) {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runLFA(args []string) int {
+	/*
+		runLFA démarre le réseau et affiche la couverture des next hops de secours (LFA et LFA
+		distants), puis les secours du routeur -r s'il est donné.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si toutes les routes sont protégées, exitFailure sinon
+	*/
+	fs := newCommandFlags("lfa")
+	router := fs.String("r", "", "routeur dont les secours sont détaillés (nom ou numéro)")
+	show := fs.Int("show", 10, "nombre maximal de routeurs les moins bien protégés affichés")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	printBackupCoverage(graph, *show)
+	if *router != "" {
+		nodes, ok := lookupRouters(graph, *router)
+		if !ok {
+			return exitUsage
+		}
+		printBackups(nodes[0])
+	}
+	for _, c := range backupCoverage(graph) {
+		if c.share() < 1 {
+			return exitFailure
+		}
+	}
+	return exitOK
+}
+
+func runPing(args []string) int {
+	/*
+		runPing démarre le réseau et envoie -count Hello d'un routeur à un autre, l'un après l'autre,
+		en affichant pour chacun le nombre de sauts, la latence de bout en bout du Hello et le temps
+		entre l'envoi du Hello et la réception du Hello Ack.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si tous les Hello ont reçu leur Hello Ack, exitFailure sinon
+	*/
+	fs := newCommandFlags("ping")
+	src := fs.String("src", "", "routeur source (nom ou numéro)")
+	dst := fs.String("dst", "", "routeur destination (nom ou numéro)")
+	count := fs.Int("count", 3, "nombre de Hello à envoyer")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	nodes, ok := lookupRouters(graph, *src, *dst)
+	if !ok {
+		return exitUsage
+	}
+	received := 0
+	for i := 0; i < *count; i++ {
+		request := newHelloRequest(nodes[0], nodes[1])
+		if missing := helloRound([]*helloRequest{request}, *helloTimeout); len(missing) > 0 {
+			continue
+		}
+		received++
+		fmt.Printf("Réponse de %s : séquence %d, %d sauts, aller %v, aller-retour %v, %d retransmissions\n", nodes[1].Name, i+1,
+			len(request.Path)-1, request.oneWay().Round(time.Microsecond), request.roundTrip().Round(time.Microsecond), request.retransmissions())
+	}
+	fmt.Printf("\n%d Hello envoyés, %d reçus, %d%% de perte.\n", *count, received, (*count-received)*100/max(*count, 1))
+	if received < *count {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runFailLink(args []string) int {
+	/*
+		runFailLink démarre le réseau, supprime un lien, attend la convergence et affiche le nombre de
+		routes modifiées. Avec -src et -dst, la route entre ces deux routeurs est affichée ensuite.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si le lien a été supprimé (et si la destination reste joignable avec -src et -dst),
+			  exitFailure sinon
+	*/
+	fs := newCommandFlags("fail-link")
+	a := fs.String("a", "", "premier routeur du lien (nom ou numéro)")
+	b := fs.String("b", "", "second routeur du lien (nom ou numéro)")
+	src := fs.String("src", "", "routeur source de la route à afficher après la panne (optionnel)")
+	dst := fs.String("dst", "", "routeur destination de la route à afficher après la panne (optionnel)")
+	show := fs.Int("show", 20, "nombre maximal de routes modifiées détaillées")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	link, ok := lookupRouters(graph, *a, *b)
+	if !ok {
+		return exitUsage
+	}
+	if !edgeExists(link[0], link[1]) {
+		fmt.Fprintf(os.Stderr, "Erreur : pas de lien entre %s et %s\n", link[0].Name, link[1].Name)
+		return exitFailure
+	}
+	before := snapshotRoutingTables(graph)
+	changeLink(graph, LinkInfo{NodeA: link[0], NodeB: link[1]}, MessageLinkDown)
+	reportRoutingDiff(graph, before, fmt.Sprintf("Lien %s - %s supprimé", link[0].Name, link[1].Name), *show)
+
+	if *src == "" && *dst == "" {
+		return exitOK
+	}
+	nodes, ok := lookupRouters(graph, *src, *dst)
+	if !ok {
+		return exitUsage
+	}
+	printRoute(nodes[0], nodes[1])
+	if !nodes[0].Route(nodes[1].Name).Reachable() {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runAddLink(args []string) int {
+	/*
+		runAddLink démarre le réseau, ajoute un lien, attend la convergence et affiche les routes modifiées.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si le lien a été ajouté, exitFailure s'il existait déjà ou si un routeur est en panne
+	*/
+	return runLinkCommand("add-link", MessageLinkUp, args)
+}
+
+func runChangeCost(args []string) int {
+	/*
+		runChangeCost démarre le réseau, change le poids d'un lien, attend la convergence et affiche les
+		routes modifiées.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si le poids a été changé, exitFailure si le lien n'existe pas
+	*/
+	return runLinkCommand("change-cost", MessageLinkCost, args)
+}
+
+func runLinkCommand(name string, messageType MessageType, args []string) int {
+	/*
+		runLinkCommand exécute add-link ou change-cost : les deux sous-commandes prennent un lien (-a, -b)
+		et un poids (-weight), et affichent le détail des routes modifiées (-show routes au plus).
+
+		Paramètres :
+			- name : le nom de la sous-commande
+			- messageType : MessageLinkUp ou MessageLinkCost
+			- args : les options de la sous-commande
+
+		Retourne :
+			- Le code de sortie de la sous-commande
+	*/
+	fs := newCommandFlags(name)
+	a := fs.String("a", "", "premier routeur du lien (nom ou numéro)")
+	b := fs.String("b", "", "second routeur du lien (nom ou numéro)")
+	weight := fs.Int("weight", 1, "poids du lien")
+	show := fs.Int("show", 20, "nombre maximal de routes modifiées détaillées")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	if *weight < 1 {
+		fmt.Fprintln(os.Stderr, "Erreur : le poids du lien doit être positif")
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	link, ok := lookupRouters(graph, *a, *b)
+	if !ok {
+		return exitUsage
+	}
+	if refusal := linkRefusal(link[0], link[1]); messageType == MessageLinkUp && refusal != "" {
+		fmt.Fprintf(os.Stderr, "Erreur : %s\n", refusal)
+		return exitFailure
+	}
+	if messageType == MessageLinkCost && !edgeExists(link[0], link[1]) {
+		fmt.Fprintf(os.Stderr, "Erreur : pas de lien entre %s et %s\n", link[0].Name, link[1].Name)
+		return exitFailure
+	}
+	event := fmt.Sprintf("Lien %s - %s ajouté (poids %d)", link[0].Name, link[1].Name, *weight)
+	if messageType == MessageLinkCost {
+		event = fmt.Sprintf("Lien %s - %s : poids %d -> %d", link[0].Name, link[1].Name, linkWeight(link[0], link[1]), *weight)
+	}
+	before := snapshotRoutingTables(graph)
+	changeLink(graph, LinkInfo{NodeA: link[0], NodeB: link[1], Weight: *weight}, messageType)
+	reportRoutingDiff(graph, before, event, *show)
+	return exitOK
+}
+
+func runFailRouter(args []string) int {
+	/*
+		runFailRouter démarre le réseau, met un routeur en panne, attend la convergence et affiche le
+		nombre de routes modifiées. Avec -src et -dst, la route entre ces deux routeurs est affichée
+		pendant la panne. Avec -recover, le routeur est ensuite redémarré et les routes modifiées par
+		son retour sont comptées à leur tour.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si le routeur a été mis en panne (et si la destination reste joignable avec -src
+			  et -dst), exitFailure sinon
+	*/
+	fs := newCommandFlags("fail-router")
+	name := fs.String("r", "", "routeur à mettre en panne (nom ou numéro)")
+	restart := fs.Bool("recover", false, "redémarrer le routeur après la convergence")
+	src := fs.String("src", "", "routeur source de la route à afficher pendant la panne (optionnel)")
+	dst := fs.String("dst", "", "routeur destination de la route à afficher pendant la panne (optionnel)")
+	show := fs.Int("show", 20, "nombre maximal de routes modifiées détaillées")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(false)
+	if graph == nil {
+		return code
+	}
+	defer stopNetwork(graph)
+
+	routers, ok := lookupRouters(graph, *name)
+	if !ok {
+		return exitUsage
+	}
+	router := routers[0]
+	var route []*Node
+	if *src != "" || *dst != "" {
+		if route, ok = lookupRouters(graph, *src, *dst); !ok {
+			return exitUsage
+		}
+	}
+
+	before := snapshotRoutingTables(graph)
+	changeRouter(graph, router, MessageRouterDown)
+	reportRoutingDiff(graph, before, fmt.Sprintf("Routeur %s en panne", router.Name), *show)
+	result := exitOK
+	if route != nil {
+		printRoute(route[0], route[1])
+		if !route[0].Route(route[1].Name).Reachable() {
+			result = exitFailure
+		}
+	}
+
+	if *restart {
+		before = snapshotRoutingTables(graph)
+		changeRouter(graph, router, MessageRouterUp)
+		reportRoutingDiff(graph, before, fmt.Sprintf("Routeur %s redémarré", router.Name), *show)
+	}
+	return result
+}
+
+func runScenarioCommand(args []string) int {
+	/*
+		runScenarioCommand démarre le réseau, joue les événements d'un fichier de scénario puis
+		affiche le bilan de chaque phase (Hello reçus et perdus, routes modifiées).
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- exitOK si tous les événements ont été exécutés et tous les Hello acquittés, exitFailure sinon
+	*/
+	fs := newCommandFlags("scenario")
+	path := fs.String("file", "", "fichier de scénario à jouer")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "Erreur : indiquer le fichier de scénario (-file)")
+		return exitUsage
+	}
+	graph, err := buildGraph(false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitUsage
+	}
+	// Le scénario est vérifié avant de démarrer le réseau
+	events, err := loadScenario(*path, &graph)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitUsage
+	}
+	if err := startNetwork(&graph); err != nil {
+		fmt.Fprintln(os.Stderr, "Erreur :", err)
+		return exitUsage
+	}
+	// Sans effet si le scénario s'est terminé par close
+	defer stopNetwork(&graph)
+
+	phases := runScenario(&graph, events)
+	printScenarioReport(phases)
+	if !scenarioSucceeded(phases) {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func runInteractive(args []string) int {
+	/*
+		runInteractive démarre le réseau, envoie un Hello depuis chaque routeur puis affiche le menu
+		interactif. La taille du graphe est demandée au clavier si -topology et -n ne sont pas donnés.
+
+		Paramètres :
+			- args : les options de la sous-commande
+
+		Retourne :
+			- Le code de sortie du programme
+	*/
+	fs := newCommandFlags("interactive")
+	if !parseCommandFlags(fs, args) {
+		return exitUsage
+	}
+	graph, code := startCommandNetwork(true)
+	if graph == nil {
+		return code
+	}
+
+	//Envoi d'un Hello Message à un noeud aléatoire depuis chaque noeud
+	//et attente des Hello Ack (au plus -hello-timeout)
+	helloRound(randomHelloRequests(graph), *helloTimeout)
+
+	interactiveMenu(graph)
+	stopNetwork(graph)
+	return exitOK
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+//**** SIMULATION À ÉVÉNEMENTS DISCRETS (HORLOGE VIRTUELLE) ****//
+
+// Modes d'exécution de la simulation (option -mode)
+const (
+	modeGoroutines = "goroutines" //une goroutine par routeur, temps réel
+	modeDES        = "des"        //simulation à événements discrets, horloge virtuelle
+)
+
+// Date de départ de l'horloge virtuelle, pour que les dates affichées soient les mêmes à chaque exécution
+var desEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Événement programmé : fonction exécutée à la date virtuelle At
+type desEvent struct {
+	At  time.Duration //date depuis le début de la simulation
+	Seq uint64        //ordre de programmation, pour départager les événements simultanés
+	Run func()
+}
+
+// File d'événements triée par date puis par ordre de programmation, utilisée avec container/heap
+type eventQueue []*desEvent
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].At != q[j].At {
+		return q[i].At < q[j].At
+	}
+	return q[i].Seq < q[j].Seq
+}
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*desEvent)) }
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	event := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return event
+}
+
+// Moteur de simulation : horloge virtuelle et file des événements à venir
+type desEngine struct {
+	graph     *Graph
+	now       time.Duration //date virtuelle actuelle depuis le début de la simulation
+	seq       uint64
+	queue     eventQueue
+	processed int64     //nombre d'événements exécutés
+	started   time.Time //date réelle de création du moteur
+}
+
+var engine *desEngine //nil en mode goroutines
+
+func newDESEngine(g *Graph) *desEngine {
+	/*
+		newDESEngine crée le moteur de simulation à événements discrets d'un graphe.
+
+		Paramètres :
+			- g : le graphe de la simulation, dont les nœuds reçoivent les messages remis par le moteur
+
+		Retourne :
+			- Le moteur, à l'instant 0 et sans événement programmé
+	*/
+	return &desEngine{graph: g, started: time.Now()}
+}
+
+func (e *desEngine) schedule(delay time.Duration, run func()) {
+	/*
+		schedule programme une fonction après un délai virtuel.
+
+		Paramètres :
+			- delay : le délai depuis la date virtuelle actuelle (un délai négatif vaut 0)
+			- run : la fonction à exécuter
+
+		Deux événements programmés à la même date sont exécutés dans l'ordre de programmation,
+		ce qui rend la simulation reproductible.
+
+		La fonction ne retourne rien.
+	*/
+	if delay < 0 {
+		delay = 0
+	}
+	e.seq++
+	heap.Push(&e.queue, &desEvent{At: e.now + delay, Seq: e.seq, Run: run})
+}
+
+func (e *desEngine) runUntil(limit time.Duration, done func() bool) bool {
+	/*
+		runUntil exécute les événements dans l'ordre de leurs dates, jusqu'à la date limit ou jusqu'à
+		ce que la condition done soit remplie.
+
+		Paramètres :
+			- limit : la date virtuelle à ne pas dépasser
+			- done : la condition d'arrêt, vérifiée avant chaque événement (nil pour aller jusqu'à limit)
+
+		Si la condition n'est pas remplie, l'horloge est avancée jusqu'à limit, même si aucun événement
+		n'est programmé d'ici là.
+
+		Retourne :
+			- true si la condition est remplie
+	*/
+	for {
+		if done != nil && done() {
+			return true
+		}
+		if len(e.queue) == 0 || e.queue[0].At > limit {
+			if limit > e.now {
+				e.now = limit
+			}
+			return done != nil && done()
+		}
+		event := heap.Pop(&e.queue).(*desEvent)
+		e.now = event.At
+		e.processed++
+		event.Run()
+	}
+}
+
+func (e *desEngine) report() {
+	/*
+		report affiche le temps simulé, le nombre d'événements exécutés et le temps réel écoulé.
+
+		La fonction ne retourne rien.
+	*/
+	fmt.Printf("Simulation à événements discrets : %v simulées, %d événements traités en %v.\n",
+		e.now, e.processed, time.Since(e.started).Round(time.Millisecond))
+}
+
+//**** ENVOIS EN COURS EN MODE GOROUTINES ****//
+
+// Suivi des envois qui survivent à la série de Hello qui les a créés : retransmissions programmées,
+// exemplaires dupliqués et messages retardés par un lien. stopNetwork les arrête et attend leur fin
+// avant de fermer les canaux, pour qu'aucun message ne soit envoyé sur un canal fermé.
+type trafficTracker struct {
+	stop    chan struct{} //fermé par shutdown pour abandonner les envois en attente
+	mu      sync.Mutex
+	stopped bool
+	timers  map[*time.Timer]struct{} //fonctions programmées par afterFunc pas encore exécutées
+	wg      sync.WaitGroup           //envois et fonctions programmées en cours d'exécution
+}
+
+var traffic = newTrafficTracker() //recréé par startNetwork
+
+func newTrafficTracker() *trafficTracker {
+	/*
+		newTrafficTracker crée le suivi des envois en cours.
+
+		Retourne :
+			- Le suivi, sans envoi en cours
+	*/
+	return &trafficTracker{stop: make(chan struct{}), timers: make(map[*time.Timer]struct{})}
+}
+
+func (t *trafficTracker) begin() bool {
+	/*
+		begin enregistre le début d'un envoi ou d'une fonction programmée, terminé par t.wg.Done().
+
+		Retourne :
+			- false si le réseau est arrêté : l'envoi doit être abandonné
+	*/
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return false
+	}
+	t.wg.Add(1)
+	return true
+}
+
+func (t *trafficTracker) afterFunc(d time.Duration, run func()) {
+	/*
+		afterFunc exécute une fonction après un délai réel, sauf si le réseau est arrêté entre-temps.
+
+		Paramètres :
+			- d : le délai
+			- run : la fonction à exécuter
+
+		La fonction ne retourne rien.
+	*/
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(d, func() {
+		t.mu.Lock()
+		delete(t.timers, timer)
+		t.mu.Unlock()
+		if !t.begin() {
+			return
+		}
+		defer t.wg.Done()
+		run()
+	})
+	t.timers[timer] = struct{}{}
+}
+
+func (t *trafficTracker) deliver(to *Node, message Message, delay time.Duration) {
+	/*
+		deliver envoie un message sur le canal d'un voisin après le délai du lien.
+
+		Paramètres :
+			- to : le voisin destinataire
+			- message : le message
+			- delay : le délai du lien
+
+		L'appel bloque jusqu'à la réception du message. Le message est abandonné si le réseau est
+		arrêté avant ou pendant l'attente.
+
+		La fonction ne retourne rien.
+	*/
+	if !t.begin() {
+		return
+	}
+	defer t.wg.Done()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-t.stop:
+			return
+		}
+	}
+	select {
+	case to.Channel <- message:
+	case <-t.stop:
+	}
+}
+
+func (t *trafficTracker) shutdown() {
+	/*
+		shutdown annule les fonctions programmées (retransmissions de Hello, mises à jour déclenchées)
+		et attend la fin des envois en cours. Un second appel est sans effet.
+
+		La fonction ne retourne rien.
+	*/
+	t.mu.Lock()
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	t.stopped = true
+	close(t.stop)
+	for timer := range t.timers {
+		timer.Stop()
+	}
+	t.timers = nil
+	t.mu.Unlock()
+	t.wg.Wait()
+}
+
+//**** OPÉRATIONS DÉPENDANT DU MODE D'EXÉCUTION ****//
+
+func clockNow() time.Time {
+	/*
+		clockNow retourne la date actuelle de la simulation.
+
+		Retourne :
+			- La date virtuelle en mode des, la date réelle sinon
+	*/
+	if engine != nil {
+		return desEpoch.Add(engine.now)
+	}
+	return time.Now()
+}
+
+func sleepFor(d time.Duration) {
+	/*
+		sleepFor laisse la simulation avancer pendant une durée.
+
+		Paramètres :
+			- d : la durée d'attente
+
+		En mode des, les événements programmés pendant cette durée sont exécutés et l'horloge
+		virtuelle avance de d, sans attente réelle.
+
+		La fonction ne retourne rien.
+	*/
+	if engine != nil {
+		engine.runUntil(engine.now+d, nil)
+		return
+	}
+	time.Sleep(d)
+}
+
+func async(run func()) {
+	/*
+		async exécute le traitement d'un message sans bloquer le routeur qui l'a reçu.
+
+		Paramètres :
+			- run : le traitement
+
+		En mode goroutines, le traitement est lancé dans une nouvelle goroutine. En mode des, il est
+		exécuté tout de suite : les envois qu'il fait sont programmés et ne bloquent jamais.
+
+		La fonction ne retourne rien.
+	*/
+	if engine != nil {
+		run()
+		return
+	}
+	go run()
+}
+
+func afterFunc(d time.Duration, run func()) {
+	/*
+		afterFunc exécute une fonction après un délai.
+
+		Paramètres :
+			- d : le délai
+			- run : la fonction à exécuter
+
+		La fonction ne retourne rien.
+	*/
+	if engine != nil {
+		engine.schedule(d, run)
+		return
+	}
+	traffic.afterFunc(d, run)
+}
+
+func transmit(from *Node, to *Node, message Message) {
+	/*
+		transmit envoie un message d'un routeur à son voisin.
+
+		Paramètres :
+			- from : le routeur qui envoie le message
+			- to : le voisin destinataire
+			- message : le message
+
+		Le lien peut perdre, altérer ou dupliquer le message (voir linkFaults). Chaque exemplaire
+		passe ensuite par la file d'émission du lien (voir linkDelay) et est perdu si elle est
+		pleine. En mode goroutines, il est envoyé sur le canal du voisin après le délai du lien
+		(l'appel bloque jusqu'à sa réception, voir deliver). En mode des, sa réception est programmée
+		après ce délai.
+
+		La fonction ne retourne rien.
+	*/
+	for _, msg := range linkFaults(from, to, message) {
+		delay, ok := linkDelay(from, to, msg)
+		if !ok {
+			continue
+		}
+		if engine != nil {
+			engine.schedule(delay, func() { dispatchMessage(engine.graph, to, msg) })
+			continue
+		}
+		traffic.deliver(to, msg, delay)
+	}
+}
+
+func sortNodes(nodes []*Node) []*Node {
+	/*
+		sortNodes trie des nœuds dans l'ordre du graphe (champ Index). Les protocoles parcourent leurs
+		voisins dans cet ordre plutôt que dans celui, aléatoire, des maps, pour que les messages soient
+		envoyés dans le même ordre à chaque exécution.
+
+		Paramètres :
+			- nodes : les nœuds à trier, triés sur place
+
+		Retourne :
+			- Les nœuds triés
+	*/
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Index < nodes[j].Index })
+	return nodes
+}
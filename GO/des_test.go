@@ -0,0 +1,160 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Changement de topologie joué par les tests de convergence, sur les indices des routeurs
+type topologyStep struct {
+	Name    string
+	A, B    int
+	Type    MessageType //MessageLinkDown ou MessageLinkUp
+	Weight  int
+	Reached bool //true si toutes les destinations restent joignables après le changement
+}
+
+func routersGraph(n int, links [][3]int) Graph {
+	/*
+		routersGraph construit un graphe de test de n routeurs (R1 à Rn) dont les liens sont donnés
+		par les indices de leurs extrémités.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- links : les liens {a, b, poids}, créés dans les deux sens dans cet ordre
+
+		Retourne :
+			- Le graphe, sans tables de routage
+	*/
+	nodes := newRouters(n)
+	for _, link := range links {
+		linkNodes(nodes[link[0]], nodes[link[1]], link[2])
+	}
+	return Graph{Nodes: nodes}
+}
+
+func runDESProtocol(t *testing.T, protocol string, links [][3]int, n int, steps []topologyStep) (time.Duration, int64) {
+	/*
+		runDESProtocol démarre un protocole de routage en mode des sur un graphe de test, joue des
+		coupures et des rétablissements de liens et vérifie après chacun que les tables ont convergé
+		vers les plus courts chemins du graphe actuel.
+
+		Paramètres :
+			- t : le test
+			- protocol : protocolDistanceVector ou protocolLinkState
+			- links : les liens du graphe {a, b, poids}
+			- n : le nombre de routeurs
+			- steps : les changements de topologie, dans l'ordre
+
+		Retourne :
+			- La date virtuelle à la fin de la simulation
+			- Le nombre d'annonces envoyées par le protocole
+	*/
+	t.Helper()
+	defer func(mode string, protocol string, edges int) {
+		*modeFlag, *protocolFlag, maxEdges = mode, protocol, edges
+		engine = nil
+	}(*modeFlag, *protocolFlag, maxEdges)
+	*modeFlag, *protocolFlag = modeDES, protocol
+	dvProtocol = newRoutingProtocol(dvProtocol.Name, dvProtocol.Messages)
+	lsProtocol = newRoutingProtocol(lsProtocol.Name, lsProtocol.Messages)
+
+	graph := routersGraph(n, links)
+	maxEdges = n
+	if err := startNetwork(&graph); err != nil {
+		t.Fatal(err)
+	}
+	checkConvergedRoutes(t, &graph, "au démarrage", true)
+	for _, step := range steps {
+		link := LinkInfo{NodeA: graph.Nodes[step.A], NodeB: graph.Nodes[step.B], Weight: step.Weight}
+		changeLink(&graph, link, step.Type)
+		checkConvergedRoutes(t, &graph, step.Name, step.Reached)
+	}
+	now := engine.now
+	sent := dvProtocol.sent.Load() + lsProtocol.sent.Load()
+	stopNetwork(&graph)
+	return now, sent
+}
+
+func checkConvergedRoutes(t *testing.T, g *Graph, step string, reached bool) {
+	/*
+		checkConvergedRoutes compare la table de chaque routeur aux plus courts chemins calculés par
+		Dijkstra sur le graphe actuel : même coût, next hop sur l'un des plus courts chemins, et
+		destinations injoignables absentes ou publiées comme telles.
+
+		Paramètres :
+			- t : le test
+			- g : le graphe de la simulation
+			- step : le changement de topologie vérifié, pour les messages d'erreur
+			- reached : true si toutes les destinations doivent être joignables
+
+		La fonction ne retourne rien.
+	*/
+	t.Helper()
+	adj := buildAdjacency(g)
+	dist := make([][]int, len(g.Nodes))
+	for i := range g.Nodes {
+		scratch := &dijkstraScratch{}
+		shortestPaths(adj, i, scratch)
+		dist[i] = scratch.Dist
+	}
+	unreachable := 0
+	for i, node := range g.Nodes {
+		for j, dest := range g.Nodes {
+			if i == j {
+				continue
+			}
+			entry := node.Route(dest.Name)
+			if dist[i][j] == infiniteDistance {
+				unreachable++
+				if entry.Reachable() {
+					t.Errorf("%s : %s -> %s joignable (coût %d) alors que le réseau est coupé", step, node.Name, dest.Name, entry.Cost)
+				}
+				continue
+			}
+			if !entry.Reachable() || entry.Cost != dist[i][j] {
+				t.Errorf("%s : %s -> %s %v ; attendu le coût %d", step, node.Name, dest.Name, entry, dist[i][j])
+				continue
+			}
+			// Le next hop est sur un plus court chemin si le lien vers lui et sa distance à la destination font le coût de la route
+			onShortestPath := false
+			for _, a := range adj[i] {
+				onShortestPath = onShortestPath || g.Nodes[a.To] == entry.NextHop && a.Weight+dist[a.To][j] == dist[i][j]
+			}
+			if !onShortestPath {
+				t.Errorf("%s : %s -> %s via %s, qui n'est sur aucun plus court chemin", step, node.Name, dest.Name, entry.NextHop.Name)
+			}
+		}
+	}
+	if reached != (unreachable == 0) {
+		t.Errorf("%s : %d routes injoignables", step, unreachable)
+	}
+}
+
+func TestProtocolConvergenceDES(t *testing.T) {
+	/*
+		TestProtocolConvergenceDES fait converger les protocoles à vecteur de distances et à états de
+		liens sur la topologie du laboratoire (deux triangles reliés par le lien R3 - R4), en coupant
+		puis en rétablissant un lien d'un triangle et le lien qui relie les deux sites. La même
+		simulation, rejouée, doit se terminer à la même date virtuelle avec le même nombre d'annonces.
+	*/
+	lab := [][3]int{{0, 1, 2}, {1, 2, 2}, {2, 0, 5}, {2, 3, 10}, {3, 4, 1}, {4, 5, 1}, {5, 3, 3}}
+	steps := []topologyStep{
+		{"coupure de R1 - R2", 0, 1, MessageLinkDown, 0, true},
+		{"coupure de R3 - R4", 2, 3, MessageLinkDown, 0, false},
+		{"rétablissement de R3 - R4", 2, 3, MessageLinkUp, 10, true},
+		{"rétablissement de R1 - R2 avec le poids 1", 0, 1, MessageLinkUp, 1, true},
+	}
+	for _, protocol := range []string{protocolDistanceVector, protocolLinkState} {
+		t.Run(protocol, func(t *testing.T) {
+			end, sent := runDESProtocol(t, protocol, lab, 6, steps)
+			if t.Failed() {
+				return
+			}
+			replayEnd, replaySent := runDESProtocol(t, protocol, lab, 6, steps)
+			if replayEnd != end || replaySent != sent {
+				t.Errorf("simulation rejouée : fin à %v avec %d annonces ; attendu %v avec %d annonces", replayEnd, replaySent, end, sent)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//**** DIFFÉRENCES DES TABLES DE ROUTAGE APRÈS UN CHANGEMENT DE TOPOLOGIE ****//
+
+// Différences entre un relevé des tables de routage et les tables actuelles
+type routingDiff struct {
+	Event       string        //changement de topologie qui a provoqué les différences
+	At          time.Time     //date du relevé des tables actuelles (horloge virtuelle en mode des)
+	Changes     []routeChange //routes dont le next hop ou le coût a changé
+	Affected    []*Node       //routeurs dont au moins une route a changé, dans l'ordre du graphe
+	Unreachable []routeChange //routes joignables avant le changement et injoignables (ou absentes) après
+}
+
+// Route d'un export JSON : next hop et coût, ou destination injoignable
+type routeStateJSON struct {
+	NextHop   string `json:"next_hop,omitempty"`
+	Cost      int    `json:"cost,omitempty"`
+	Reachable bool   `json:"reachable"`
+}
+
+// Route modifiée d'un export JSON, Before ou After étant absent si la destination n'était pas ou plus dans la table
+type routeChangeJSON struct {
+	Source      string          `json:"source"`
+	Destination string          `json:"destination"`
+	Before      *routeStateJSON `json:"before"`
+	After       *routeStateJSON `json:"after"`
+}
+
+// Paire source -> destination d'un export JSON
+type routePairJSON struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// Export JSON des différences (voir writeRoutingDiffJSON)
+type routingDiffJSON struct {
+	Event           string            `json:"event"`
+	Time            time.Time         `json:"time"`
+	Protocol        string            `json:"protocol"`
+	ChangedRoutes   int               `json:"changed_routes"`
+	AffectedRouters []string          `json:"affected_routers"`
+	Unreachable     []routePairJSON   `json:"unreachable"`
+	Changes         []routeChangeJSON `json:"changes"`
+}
+
+var diffExportCount = 0 //numéro du prochain fichier écrit dans le dossier -diff
+
+func diffRoutingTables(g *Graph, before map[*Node]map[string]*RoutingEntry, event string) routingDiff {
+	/*
+		diffRoutingTables compare un relevé des tables de routage aux tables actuelles.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- before : le relevé fait par snapshotRoutingTables avant le changement
+			- event : la description du changement, reprise dans l'affichage et l'export JSON
+
+		Retourne :
+			- Les routes modifiées, les routeurs touchés et les destinations devenues injoignables
+	*/
+	diff := routingDiff{Event: event, At: clockNow(), Changes: routeChanges(g, before)}
+	var last *Node
+	for _, change := range diff.Changes {
+		if source := change.entry().Source; source != last {
+			diff.Affected = append(diff.Affected, source)
+			last = source
+		}
+		if change.Before.Reachable() && !change.After.Reachable() {
+			diff.Unreachable = append(diff.Unreachable, change)
+		}
+	}
+	return diff
+}
+
+func (change routeChange) entry() *RoutingEntry {
+	/*
+		entry retourne l'une des deux entrées de la route modifiée, pour en connaître la source et la
+		destination.
+
+		Retourne :
+			- L'entrée actuelle, ou l'entrée du relevé si la destination a disparu de la table
+	*/
+	if change.After != nil {
+		return change.After
+	}
+	return change.Before
+}
+
+func printRoutingDiff(diff routingDiff, limit int) {
+	/*
+		printRoutingDiff affiche le nombre de routes modifiées et de routeurs touchés, le détail des
+		premières routes (ancien et nouveau coût et next hop) et les destinations devenues injoignables.
+
+		Paramètres :
+			- diff : les différences calculées par diffRoutingTables
+			- limit : le nombre maximal de routes détaillées dans chaque liste
+
+		La fonction ne retourne rien.
+	*/
+	fmt.Printf("%s : %d routes modifiées sur %d routeurs.\n", diff.Event, len(diff.Changes), len(diff.Affected))
+	for i, change := range diff.Changes {
+		if i == limit {
+			fmt.Printf("  ... et %d autres\n", len(diff.Changes)-limit)
+			break
+		}
+		entry := change.entry()
+		fmt.Printf("  %s -> %s : %s => %s\n", entry.Source.Name, entry.Destination.Name,
+			describeRoute(change.Before), describeRoute(change.After))
+	}
+	if len(diff.Unreachable) == 0 {
+		return
+	}
+	pairs := make([]string, 0, limit)
+	for i, change := range diff.Unreachable {
+		if i == limit {
+			pairs = append(pairs, fmt.Sprintf("... et %d autres", len(diff.Unreachable)-limit))
+			break
+		}
+		entry := change.entry()
+		pairs = append(pairs, entry.Source.Name+" -> "+entry.Destination.Name)
+	}
+	fmt.Printf("%d destinations devenues injoignables : %s\n", len(diff.Unreachable), strings.Join(pairs, ", "))
+}
+
+func routeStateToJSON(entry *RoutingEntry) *routeStateJSON {
+	/*
+		routeStateToJSON convertit une entrée de table de routage pour l'export JSON.
+
+		Paramètres :
+			- entry : l'entrée, nil si la destination est absente de la table
+
+		Retourne :
+			- Le next hop et le coût de l'entrée, nil si elle est absente
+	*/
+	switch {
+	case entry == nil:
+		return nil
+	case !entry.Reachable():
+		return &routeStateJSON{}
+	case entry.NextHop == nil:
+		return &routeStateJSON{Cost: entry.Cost, Reachable: true}
+	default:
+		return &routeStateJSON{NextHop: entry.NextHop.Name, Cost: entry.Cost, Reachable: true}
+	}
+}
+
+func writeRoutingDiffJSON(w io.Writer, diff routingDiff) error {
+	/*
+		writeRoutingDiffJSON écrit les différences au format JSON : le changement, la date, le protocole,
+		le nombre de routes modifiées, les routeurs touchés, les paires devenues injoignables et le
+		détail de chaque route modifiée.
+
+		Paramètres :
+			- w : la destination de l'export
+			- diff : les différences calculées par diffRoutingTables
+
+		Retourne :
+			- Une erreur si l'écriture a échoué
+	*/
+	export := routingDiffJSON{
+		Event:           diff.Event,
+		Time:            diff.At,
+		Protocol:        *protocolFlag,
+		ChangedRoutes:   len(diff.Changes),
+		AffectedRouters: make([]string, 0, len(diff.Affected)),
+		Unreachable:     make([]routePairJSON, 0, len(diff.Unreachable)),
+		Changes:         make([]routeChangeJSON, 0, len(diff.Changes)),
+	}
+	for _, node := range diff.Affected {
+		export.AffectedRouters = append(export.AffectedRouters, node.Name)
+	}
+	for _, change := range diff.Unreachable {
+		entry := change.entry()
+		export.Unreachable = append(export.Unreachable, routePairJSON{Source: entry.Source.Name, Destination: entry.Destination.Name})
+	}
+	for _, change := range diff.Changes {
+		entry := change.entry()
+		export.Changes = append(export.Changes, routeChangeJSON{
+			Source:      entry.Source.Name,
+			Destination: entry.Destination.Name,
+			Before:      routeStateToJSON(change.Before),
+			After:       routeStateToJSON(change.After),
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
+
+func dumpRoutingDiff(diff routingDiff) {
+	/*
+		dumpRoutingDiff écrit les différences dans un nouveau fichier JSON numéroté du dossier donné
+		par l'option -diff, si elle est utilisée (routes_000.json, routes_001.json, ...).
+
+		Paramètres :
+			- diff : les différences calculées par diffRoutingTables
+
+		La fonction ne retourne rien, une erreur d'écriture est seulement affichée.
+	*/
+	if *diffDir == "" {
+		return
+	}
+	if err := os.MkdirAll(*diffDir, 0o755); err != nil {
+		fmt.Println("Export des routes modifiées impossible :", err)
+		return
+	}
+	path := filepath.Join(*diffDir, fmt.Sprintf("routes_%03d.json", diffExportCount))
+	diffExportCount++
+	file, err := os.Create(path)
+	if err == nil {
+		err = writeRoutingDiffJSON(file, diff)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Println("Export des routes modifiées impossible :", err)
+		return
+	}
+	fmt.Printf("Routes modifiées exportées dans %s.\n", path)
+}
+
+func reportRoutingDiff(g *Graph, before map[*Node]map[string]*RoutingEntry, event string, limit int) routingDiff {
+	/*
+		reportRoutingDiff affiche les différences des tables de routage depuis un relevé et les exporte
+		en JSON si l'option -diff est utilisée. Elle est appelée après chaque changement de topologie
+		(lien ajouté, supprimé ou modifié, routeur en panne ou redémarré).
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- before : le relevé fait par snapshotRoutingTables avant le changement
+			- event : la description du changement
+			- limit : le nombre maximal de routes détaillées
+
+		Retourne :
+			- Les différences, pour que l'appelant puisse les exploiter
+	*/
+	diff := diffRoutingTables(g, before, event)
+	printRoutingDiff(diff, limit)
+	dumpRoutingDiff(diff)
+	return diff
+}
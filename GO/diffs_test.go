@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffRoutingTables(t *testing.T) {
+	/*
+		TestDiffRoutingTables coupe l'un après l'autre deux liens d'un petit graphe (R1 - R2 - R3 - R4,
+		avec le raccourci R1 - R3 de poids 3) et compare les routes modifiées, les routeurs touchés et
+		les destinations devenues injoignables aux valeurs calculées à la main.
+	*/
+	graph := routersGraph(4, [][3]int{{0, 1, 1}, {1, 2, 1}, {0, 2, 3}, {2, 3, 1}})
+	constructAllRoutingTables(&graph)
+	tests := []struct {
+		name        string
+		a, b        int
+		changes     []string
+		affected    []string
+		unreachable []string
+	}{
+		{"coupure de R2 - R3", 1, 2, []string{
+			"R1 -> R3 : coût 2 via R2 => coût 3 via R3",
+			"R1 -> R4 : coût 3 via R2 => coût 4 via R3",
+			"R2 -> R3 : coût 1 via R3 => coût 4 via R1",
+			"R2 -> R4 : coût 2 via R3 => coût 5 via R1",
+			"R3 -> R1 : coût 2 via R2 => coût 3 via R1",
+			"R3 -> R2 : coût 1 via R2 => coût 4 via R1",
+			"R4 -> R1 : coût 3 via R3 => coût 4 via R3",
+			"R4 -> R2 : coût 2 via R3 => coût 5 via R3",
+		}, []string{"R1", "R2", "R3", "R4"}, nil},
+		{"coupure de R3 - R4", 2, 3, []string{
+			"R1 -> R4 : coût 4 via R3 => injoignable",
+			"R2 -> R4 : coût 5 via R1 => injoignable",
+			"R3 -> R4 : coût 1 via R4 => injoignable",
+			"R4 -> R1 : coût 4 via R3 => injoignable",
+			"R4 -> R2 : coût 5 via R3 => injoignable",
+			"R4 -> R3 : coût 1 via R3 => injoignable",
+		}, []string{"R1", "R2", "R3", "R4"}, []string{"R1 -> R4", "R2 -> R4", "R3 -> R4", "R4 -> R1", "R4 -> R2", "R4 -> R3"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := snapshotRoutingTables(&graph)
+			nodeA, nodeB := graph.Nodes[test.a], graph.Nodes[test.b]
+			removeEdge(nodeA, nodeB)
+			removeEdge(nodeB, nodeA)
+			constructAllRoutingTables(&graph)
+
+			diff := diffRoutingTables(&graph, before, test.name)
+			var changes, affected, unreachable []string
+			for _, change := range diff.Changes {
+				entry := change.entry()
+				changes = append(changes, fmt.Sprintf("%s -> %s : %s => %s", entry.Source.Name, entry.Destination.Name,
+					describeRoute(change.Before), describeRoute(change.After)))
+			}
+			for _, node := range diff.Affected {
+				affected = append(affected, node.Name)
+			}
+			for _, change := range diff.Unreachable {
+				unreachable = append(unreachable, change.entry().Source.Name+" -> "+change.entry().Destination.Name)
+			}
+			if !reflect.DeepEqual(changes, test.changes) {
+				t.Errorf("routes modifiées :\n%s\nattendu :\n%s", strings.Join(changes, "\n"), strings.Join(test.changes, "\n"))
+			}
+			if !reflect.DeepEqual(affected, test.affected) {
+				t.Errorf("routeurs touchés %v ; attendu %v", affected, test.affected)
+			}
+			if !reflect.DeepEqual(unreachable, test.unreachable) {
+				t.Errorf("routes devenues injoignables %v ; attendu %v", unreachable, test.unreachable)
+			}
+		})
+	}
+}
+
+func TestWriteRoutingDiffJSON(t *testing.T) {
+	/*
+		TestWriteRoutingDiffJSON exporte les différences provoquées par l'arrivée puis le départ d'un
+		routeur R4 relié à R1, sur un graphe où R3 est isolé : "before" vaut null pour une destination
+		qui n'était pas dans la table, "after" vaut null pour une destination qui en a disparu, et une
+		destination injoignable est exportée avec "reachable": false.
+	*/
+	graph := routersGraph(3, [][3]int{{0, 1, 1}})
+	constructAllRoutingTables(&graph)
+
+	export := func(before map[*Node]map[string]*RoutingEntry) (routingDiffJSON, string) {
+		var buffer bytes.Buffer
+		if err := writeRoutingDiffJSON(&buffer, diffRoutingTables(&graph, before, "test")); err != nil {
+			t.Fatal(err)
+		}
+		var decoded routingDiffJSON
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatalf("export JSON illisible : %v\n%s", err, buffer.String())
+		}
+		return decoded, buffer.String()
+	}
+	find := func(diff routingDiffJSON, source, destination string) routeChangeJSON {
+		for _, change := range diff.Changes {
+			if change.Source == source && change.Destination == destination {
+				return change
+			}
+		}
+		t.Fatalf("route %s -> %s absente de l'export", source, destination)
+		return routeChangeJSON{}
+	}
+
+	before := snapshotRoutingTables(&graph)
+	r1 := graph.Nodes[0]
+	r4 := &Node{Name: "R4", Channel: make(chan Message)}
+	addEdge(r1, &Edge{To: r4, Weight: 2})
+	addEdge(r4, &Edge{To: r1, Weight: 2})
+	graph.Nodes = append(graph.Nodes, r4)
+	constructAllRoutingTables(&graph)
+
+	arrival, raw := export(before)
+	if !strings.Contains(raw, `"before": null`) {
+		t.Errorf("une destination absente avant le changement doit être exportée avec \"before\": null :\n%s", raw)
+	}
+	if change := find(arrival, "R2", "R4"); change.Before != nil || change.After == nil ||
+		*change.After != (routeStateJSON{NextHop: "R1", Cost: 3, Reachable: true}) {
+		t.Errorf("R2 -> R4 = %+v ; attendu before null, after coût 3 via R1", change)
+	}
+	if change := find(arrival, "R3", "R4"); change.Before != nil || change.After == nil || change.After.Reachable {
+		t.Errorf("R3 -> R4 = %+v ; attendu before null, after injoignable", change)
+	}
+	if len(arrival.Unreachable) != 0 {
+		t.Errorf("routes devenues injoignables %v ; attendu aucune", arrival.Unreachable)
+	}
+
+	before = snapshotRoutingTables(&graph)
+	removeEdge(r1, r4)
+	graph.Nodes = graph.Nodes[:3]
+	constructAllRoutingTables(&graph)
+
+	departure, raw := export(before)
+	if !strings.Contains(raw, `"after": null`) {
+		t.Errorf("une destination disparue de la table doit être exportée avec \"after\": null :\n%s", raw)
+	}
+	if change := find(departure, "R1", "R4"); change.After != nil || change.Before == nil || !change.Before.Reachable {
+		t.Errorf("R1 -> R4 = %+v ; attendu before joignable, after null", change)
+	}
+	if departure.ChangedRoutes != 3 || len(departure.Unreachable) != 2 {
+		t.Errorf("%d routes modifiées, %d devenues injoignables ; attendu 3 et 2 (%+v)", departure.ChangedRoutes, len(departure.Unreachable), departure)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"container/heap"
+	"sync"
+)
+
+//**** DIJKSTRA AVEC FILE DE PRIORITÉ ****//
+
+const infiniteDistance = 1<<31 - 1 //distance d'un nœud injoignable
+
+// Structure définissant un lien vers le nœud d'indice To dans la liste d'adjacence
+type arc struct {
+	To     int
+	Weight int
+}
+
+// Élément de la file de priorité : un nœud et la distance avec laquelle il a été ajouté
+type queueItem struct {
+	Node int
+	Dist int
+}
+
+// File de priorité (tas binaire) triée par distance croissante, utilisée avec container/heap
+type distanceQueue []queueItem
+
+func (q distanceQueue) Len() int            { return len(q) }
+func (q distanceQueue) Less(i, j int) bool  { return q[i].Dist < q[j].Dist }
+func (q distanceQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distanceQueue) Push(x interface{}) { *q = append(*q, x.(queueItem)) }
+func (q *distanceQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// Tableaux de travail de Dijkstra, réutilisés d'un calcul à l'autre par un même worker
+type dijkstraScratch struct {
+	Dist     []int   //distance depuis la source
+	FirstHop []int   //indice du premier saut depuis la source, -1 si injoignable
+	Parent   []int   //indice du nœud précédent sur le plus court chemin, -1 pour la source et les injoignables
+	Hops     []int   //nombre de sauts du plus court chemin
+	Visited  []bool  //nœuds dont la distance est définitive
+	NextHops [][]int //indices de tous les premiers sauts des plus courts chemins (ECMP), FirstHop en premier
+	Queue    distanceQueue
+}
+
+var scratchPool = sync.Pool{New: func() interface{} { return &dijkstraScratch{} }}
+
+func (s *dijkstraScratch) reset(n int) {
+	/*
+		reset prépare les tableaux de travail pour un graphe de n nœuds, en ne réallouant
+		que si les tableaux actuels sont trop petits.
+
+		Paramètres :
+			- n : le nombre de nœuds du graphe
+
+		La fonction ne retourne rien.
+	*/
+	if cap(s.Dist) < n {
+		s.Dist = make([]int, n)
+		s.FirstHop = make([]int, n)
+		s.Parent = make([]int, n)
+		s.Hops = make([]int, n)
+		s.Visited = make([]bool, n)
+		s.NextHops = make([][]int, n)
+	}
+	s.Dist = s.Dist[:n]
+	s.FirstHop = s.FirstHop[:n]
+	s.Parent = s.Parent[:n]
+	s.Hops = s.Hops[:n]
+	s.Visited = s.Visited[:n]
+	s.NextHops = s.NextHops[:n]
+	for i := 0; i < n; i++ {
+		s.Dist[i] = infiniteDistance
+		s.FirstHop[i] = -1
+		s.Parent[i] = -1
+		s.Hops[i] = 0
+		s.Visited[i] = false
+		s.NextHops[i] = s.NextHops[i][:0]
+	}
+	s.Queue = s.Queue[:0]
+}
+
+func buildAdjacency(g *Graph) [][]arc {
+	/*
+		buildAdjacency numérote les nœuds du graphe (champ Index) et construit la liste d'adjacence
+		correspondante, sur laquelle Dijkstra travaille avec des indices entiers plutôt qu'avec des maps.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds et des liens
+
+		Retourne :
+			- Pour chaque indice de nœud, la liste de ses liens sortants
+	*/
+	for i, node := range g.Nodes {
+		node.Index = i
+	}
+	adj := make([][]arc, len(g.Nodes))
+	for i, node := range g.Nodes {
+		adj[i] = make([]arc, 0, len(node.Edges))
+		for _, edge := range node.Edges {
+			adj[i] = append(adj[i], arc{To: edge.To.Index, Weight: edge.Weight})
+		}
+	}
+	return adj
+}
+
+func shortestPaths(adj [][]arc, src int, s *dijkstraScratch) {
+	/*
+		shortestPaths calcule les plus courts chemins depuis src avec une file de priorité.
+
+		Paramètres :
+			- adj : la liste d'adjacence construite par buildAdjacency
+			- src : l'indice du nœud source
+			- s : les tableaux de travail, remplis avec les distances, les premiers sauts, les
+			  prédécesseurs et le nombre de sauts de chaque plus court chemin
+
+		En cas d'égalité, Parent et FirstHop gardent le premier chemin trouvé, et les premiers sauts
+		des autres chemins de même coût sont ajoutés à NextHops (routage ECMP, voir ecmp.go).
+
+		Les nœuds sont ajoutés à la file à chaque amélioration de leur distance et les entrées
+		périmées sont ignorées lorsqu'on les retire, ce qui donne une complexité en O(E log V)
+		au lieu de O(V²) avec la recherche linéaire du minimum.
+
+		La fonction ne retourne rien.
+	*/
+	s.reset(len(adj))
+	s.Dist[src] = 0
+	s.FirstHop[src] = src
+	heap.Push(&s.Queue, queueItem{Node: src, Dist: 0})
+
+	for s.Queue.Len() > 0 {
+		item := heap.Pop(&s.Queue).(queueItem)
+		u := item.Node
+		if s.Visited[u] || item.Dist > s.Dist[u] {
+			continue
+		}
+		s.Visited[u] = true
+		for _, a := range adj[u] {
+			alt := s.Dist[u] + a.Weight
+			if alt < s.Dist[a.To] {
+				s.Dist[a.To] = alt
+				s.Parent[a.To] = u
+				s.Hops[a.To] = s.Hops[u] + 1
+				if u == src {
+					s.FirstHop[a.To] = a.To
+					s.NextHops[a.To] = append(s.NextHops[a.To][:0], a.To)
+				} else {
+					s.FirstHop[a.To] = s.FirstHop[u]
+					s.NextHops[a.To] = append(s.NextHops[a.To][:0], s.NextHops[u]...)
+				}
+				heap.Push(&s.Queue, queueItem{Node: a.To, Dist: alt})
+			} else if alt == s.Dist[a.To] {
+				if u == src {
+					s.NextHops[a.To] = mergeHops(s.NextHops[a.To], []int{a.To})
+				} else {
+					s.NextHops[a.To] = mergeHops(s.NextHops[a.To], s.NextHops[u])
+				}
+			}
+		}
+	}
+}
+
+func mergeHops(hops []int, others []int) []int {
+	/*
+		mergeHops ajoute à un ensemble de premiers sauts ceux d'un autre chemin de même coût.
+
+		Paramètres :
+			- hops : les premiers sauts déjà connus, dans l'ordre où ils ont été trouvés
+			- others : les premiers sauts à ajouter
+
+		Retourne :
+			- hops complété des sauts de others qu'il ne contenait pas encore
+	*/
+	for _, hop := range others {
+		known := false
+		for _, h := range hops {
+			if h == hop {
+				known = true
+				break
+			}
+		}
+		if !known {
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
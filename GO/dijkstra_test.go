@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func undirected(n int, links [][3]int) [][]arc {
+	/*
+		undirected construit la liste d'adjacence d'un graphe de test dont chaque lien existe dans les
+		deux sens avec le même poids.
+
+		Paramètres :
+			- n : le nombre de nœuds
+			- links : les liens {a, b, poids}
+
+		Retourne :
+			- La liste d'adjacence, dans l'ordre des liens
+	*/
+	adj := make([][]arc, n)
+	for _, link := range links {
+		adj[link[0]] = append(adj[link[0]], arc{To: link[1], Weight: link[2]})
+		adj[link[1]] = append(adj[link[1]], arc{To: link[0], Weight: link[2]})
+	}
+	return adj
+}
+
+func TestShortestPathsECMP(t *testing.T) {
+	/*
+		TestShortestPathsECMP vérifie la distance et l'ensemble des premiers sauts de même coût
+		calculés depuis le nœud 0 sur de petits graphes dont la réponse est connue.
+	*/
+	tests := []struct {
+		name     string
+		n        int
+		links    [][3]int
+		dst      int
+		dist     int
+		hops     int
+		nextHops []int //triés, nil si la destination est injoignable
+	}{
+		{"carré de poids égaux", 4, [][3]int{{0, 1, 1}, {1, 3, 1}, {0, 2, 1}, {2, 3, 1}}, 3, 2, 2, []int{1, 2}},
+		{"carré déséquilibré", 4, [][3]int{{0, 1, 1}, {1, 3, 1}, {0, 2, 1}, {2, 3, 2}}, 3, 2, 2, []int{1}},
+		{"lien direct aussi cher que deux sauts", 3, [][3]int{{0, 1, 2}, {0, 2, 1}, {2, 1, 1}}, 1, 2, 1, []int{1, 2}},
+		{"deux losanges en cascade", 7, [][3]int{{0, 1, 1}, {0, 2, 1}, {1, 3, 1}, {2, 3, 1}, {3, 4, 1}, {3, 5, 1}, {4, 6, 1}, {5, 6, 1}}, 6, 4, 4, []int{1, 2}},
+		{"trois chemins de longueurs différentes", 5, [][3]int{{0, 1, 3}, {1, 4, 1}, {0, 2, 1}, {2, 3, 1}, {3, 4, 2}, {0, 4, 4}}, 4, 4, 1, []int{1, 2, 4}},
+		{"destination injoignable", 4, [][3]int{{0, 1, 1}, {1, 2, 1}}, 3, infiniteDistance, 0, nil},
+	}
+	scratch := &dijkstraScratch{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shortestPaths(undirected(test.n, test.links), 0, scratch)
+			if scratch.Dist[test.dst] != test.dist || scratch.Hops[test.dst] != test.hops {
+				t.Errorf("distance %d en %d sauts ; attendu %d en %d sauts", scratch.Dist[test.dst], scratch.Hops[test.dst], test.dist, test.hops)
+			}
+			nextHops := append([]int(nil), scratch.NextHops[test.dst]...)
+			if len(nextHops) > 0 && nextHops[0] != scratch.FirstHop[test.dst] {
+				t.Errorf("NextHops = %v ne commence pas par FirstHop = %d", nextHops, scratch.FirstHop[test.dst])
+			}
+			if test.nextHops == nil && scratch.FirstHop[test.dst] != -1 {
+				t.Errorf("FirstHop = %d pour une destination injoignable", scratch.FirstHop[test.dst])
+			}
+			sort.Ints(nextHops)
+			if len(nextHops) == 0 {
+				nextHops = nil
+			}
+			if !reflect.DeepEqual(nextHops, test.nextHops) {
+				t.Errorf("premiers sauts %v ; attendu %v", nextHops, test.nextHops)
+			}
+		})
+	}
+}
+
+func TestFlowHashPerRouter(t *testing.T) {
+	/*
+		TestFlowHashPerRouter vérifie que deux routeurs qui choisissent chacun entre deux next hops ne
+		font pas le même choix pour tous les flux (polarisation des empreintes) : environ la moitié
+		des flux doivent prendre le même côté aux deux étages.
+	*/
+	src, dst := &Node{Name: "R1"}, &Node{Name: "R9"}
+	first, second := &Node{Name: "R2"}, &Node{Name: "R5"}
+	const flows = 1000
+	same := 0
+	for flow := uint64(1); flow <= flows; flow++ {
+		message := Message{Source: src, Destination: dst, Flow: flow}
+		if flowHash(first, message)%2 == flowHash(second, message)%2 {
+			same++
+		}
+	}
+	if same < flows*2/5 || same > flows*3/5 {
+		t.Errorf("%d flux sur %d font le même choix aux deux étages ; attendu environ la moitié", same, flows)
+	}
+}
+
+//**** COMPARAISON DES DEUX VERSIONS DE DIJKSTRA ****//
+
+const benchMaxEdges = 5 //nombre maximal d'interfaces des graphes de benchmark
+
+var benchSizes = []int{100, 1000, 10000} //tailles des graphes de benchmark
+
+func benchmarkGraph(b *testing.B, n int) Graph {
+	/*
+		benchmarkGraph génère le graphe aléatoire d'un benchmark, toujours le même pour une taille donnée.
+
+		Paramètres :
+			- b : le benchmark
+			- n : le nombre de nœuds du graphe
+
+		Retourne :
+			- Le graphe
+	*/
+	b.Helper()
+	graph, err := initRandomGraph(n, benchMaxEdges, defaultWeights, rand.New(rand.NewSource(1)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return graph
+}
+
+func BenchmarkDijkstraHeap(b *testing.B) {
+	/*
+		BenchmarkDijkstraHeap mesure la version de Dijkstra avec file de priorité : une opération est
+		le calcul de la table de routage d'un routeur (les sources changent à chaque opération).
+		Le temps de constructAllRoutingTables sur un seul cœur est environ N fois celui d'une opération.
+	*/
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("N=%d", n), func(b *testing.B) {
+			graph := benchmarkGraph(b, n)
+			adj := buildAdjacency(&graph)
+			scratch := scratchPool.Get().(*dijkstraScratch)
+			defer scratchPool.Put(scratch)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				Dijkstra(&graph, graph.Nodes[i%n], adj, scratch)
+			}
+		})
+	}
+}
+
+func BenchmarkDijkstraMap(b *testing.B) {
+	/*
+		BenchmarkDijkstraMap mesure la première version de Dijkstra (maps et recherche linéaire du
+		minimum) sur les mêmes graphes que BenchmarkDijkstraHeap.
+	*/
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("N=%d", n), func(b *testing.B) {
+			graph := benchmarkGraph(b, n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				dijkstraMap(&graph, graph.Nodes[i%n])
+			}
+		})
+	}
+}
+
+func dijkstraMap(g *Graph, start *Node) {
+	/*
+		dijkstraMap est la première version de Dijkstra, conservée pour les benchmarks.
+
+		Paramètres :
+			- g : Le graphe global contenant l'ensemble des nœuds et des liens
+			- start : Le nœud de départ à partir duquel l'algorithme de Dijkstra est lancé
+
+		La fonction alloue trois maps à chaque appel et minDist parcourt tous les nœuds non visités
+		à chaque itération : le calcul d'une table est en O(V²) et celui de toutes les tables en O(V³).
+
+		La fonction ne retourne rien.
+	*/
+	unvisited := make(map[*Node]struct{})
+	distances := make(map[*Node]int)
+	next_hop := make(map[*Node]*Node)
+	for _, node := range g.Nodes {
+		if node == start {
+			distances[node] = 0
+			next_hop[node] = node
+		} else {
+			distances[node] = infiniteDistance
+		}
+		unvisited[node] = struct{}{}
+	}
+
+	for len(unvisited) != 0 {
+		u := minDist(unvisited, distances)
+		if u == nil {
+			break
+		}
+		delete(unvisited, u)
+		for _, e := range u.Edges {
+			v := e.To
+			alt := distances[u] + e.Weight
+			if alt < distances[v] {
+				distances[v] = alt
+				if u == start {
+					next_hop[v] = v
+				} else {
+					next_hop[v] = next_hop[u]
+				}
+			}
+		}
+	}
+	start.RoutingTable = make(map[string]*RoutingEntry)
+	for destNode := range distances {
+		start.RoutingTable[destNode.Name] = &RoutingEntry{Source: start, Destination: destNode, NextHop: next_hop[destNode], Cost: distances[destNode]}
+	}
+}
+
+func minDist(unvisited map[*Node]struct{}, distances map[*Node]int) *Node {
+	/*
+		minDist retourne le nœud non visité ayant la distance minimale dans la map de distances.
+
+		Paramètres :
+			- unvisited : Une map contenant les nœuds non visités
+			- distances : Une map contenant les distances minimales actuelles pour chaque nœud
+
+		Retourne :
+			- Le nœud non visité ayant la distance minimale, ou nil si la map est vide
+	*/
+	min := infiniteDistance
+	var n *Node
+	for node := range unvisited {
+		if distances[node] < min {
+			min = distances[node]
+			n = node
+		}
+	}
+	return n
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//**** PROTOCOLE À VECTEUR DE DISTANCES (TYPE RIP) ****//
+
+// Annonce d'une destination dans un vecteur de distances
+type dvAdvert struct {
+	Cost int
+	Hops int
+}
+
+// Vecteur de distances envoyé à un voisin, contenu des messages MessageDistanceVector
+type DistanceVectorUpdate map[*Node]dvAdvert
+
+// Route apprise par le protocole à vecteur de distances
+type dvRoute struct {
+	Cost      int //dvInfinity si la route est empoisonnée
+	Hops      int
+	NextHop   *Node     //voisin qui a annoncé la route
+	Refreshed time.Time //dernière annonce de cette route reçue du next hop
+}
+
+// État du protocole à vecteur de distances d'un nœud, qui ne connaît que ses voisins et leurs annonces
+type DistanceVector struct {
+	mu        sync.Mutex
+	routes    map[*Node]*dvRoute
+	neighbors map[*Node]int //poids du lien vers chaque voisin
+	triggered bool          //une mise à jour déclenchée est programmée
+}
+
+var dvProtocol = newRoutingProtocol("Vecteurs de distances", "vecteurs") //suivi des annonces et de la convergence
+
+// Enregistrement du traitement des annonces du protocole
+func init() {
+	registerHandler(MessageDistanceVector, "distance vector", func(g *Graph, node *Node, message Message) {
+		handleDistanceVector(node, message)
+	})
+}
+
+func startDistanceVector(g *Graph) {
+	/*
+		startDistanceVector démarre le protocole à vecteur de distances sur tous les nœuds du graphe.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Chaque nœud ne connaît au départ que lui-même (coût 0) et le poids des liens vers ses voisins.
+		Il apprend les autres destinations uniquement par les vecteurs que ses voisins lui envoient sur
+		son canal. Une goroutine par nœud envoie le vecteur complet toutes les dvPeriod (mises à jour
+		périodiques) et fait expirer les routes qui ne sont plus annoncées. Les goroutines processMessages
+		doivent être lancées pour que les vecteurs soient reçus.
+
+		La fonction ne retourne rien.
+	*/
+	dvProtocol.markEvent()
+	for _, node := range g.Nodes {
+		dv := &DistanceVector{routes: make(map[*Node]*dvRoute), neighbors: make(map[*Node]int)}
+		dv.routes[node] = &dvRoute{Cost: 0, NextHop: node, Refreshed: clockNow()}
+		for _, edge := range node.Edges {
+			dv.neighbors[edge.To] = edge.Weight
+		}
+		node.DV = dv
+		dv.mu.Lock()
+		publishDistanceVector(node)
+		dv.mu.Unlock()
+	}
+	for _, node := range g.Nodes {
+		node := node
+		dvProtocol.every(*dvPeriod, func(now time.Time) { distanceVectorTick(node, now) })
+		advertiseDistanceVector(node)
+	}
+}
+
+func stopDistanceVector() {
+	/*
+		stopDistanceVector arrête les annonces périodiques et attend la fin des envois en cours,
+		pour que les canaux puissent ensuite être fermés sans qu'un vecteur y soit encore envoyé.
+
+		La fonction ne retourne rien.
+	*/
+	dvProtocol.shutdown()
+}
+
+func distanceVectorTick(node *Node, now time.Time) {
+	/*
+		distanceVectorTick est appelée à chaque période : elle fait expirer les routes que leur next hop
+		n'annonce plus depuis 6 périodes (comme RIP avec ses mises à jour toutes les 30 s et son délai
+		d'expiration de 180 s), puis envoie le vecteur de distances du nœud à ses voisins.
+
+		Paramètres :
+			- node : le nœud dont on envoie les annonces
+			- now : la date du tick
+
+		Un routeur en panne n'envoie rien.
+
+		La fonction ne retourne rien.
+	*/
+	if node.Failed() {
+		return
+	}
+	dv := node.DV
+	dv.mu.Lock()
+	changed := false
+	for dest, route := range dv.routes {
+		if dest != node && route.Cost < *dvInfinity && now.Sub(route.Refreshed) > 6**dvPeriod {
+			logDistanceVectorChange(node, dest, route, *dvInfinity, route.Hops, route.NextHop, "expirée")
+			route.Cost = *dvInfinity
+			changed = true
+		}
+	}
+	if changed {
+		publishDistanceVector(node)
+	}
+	dv.mu.Unlock()
+	advertiseDistanceVector(node)
+}
+
+func advertiseDistanceVector(node *Node) {
+	/*
+		advertiseDistanceVector envoie le vecteur de distances du nœud à chacun de ses voisins.
+
+		Paramètres :
+			- node : le nœud qui annonce ses routes
+
+		Le vecteur est construit pour chaque voisin : avec le split horizon, les routes apprises par
+		ce voisin ne lui sont pas annoncées, et avec le poison reverse elles lui sont annoncées avec
+		un coût infini.
+
+		La fonction ne retourne rien.
+	*/
+	dv := node.DV
+	dv.mu.Lock()
+	neighbors := make([]*Node, 0, len(dv.neighbors))
+	for neighbor := range dv.neighbors {
+		neighbors = append(neighbors, neighbor)
+	}
+	sortNodes(neighbors)
+	vectors := make([]DistanceVectorUpdate, len(neighbors))
+	for i, neighbor := range neighbors {
+		vector := make(DistanceVectorUpdate, len(dv.routes))
+		for dest, route := range dv.routes {
+			if route.NextHop == neighbor && dest != node {
+				if *dvPoisonReverse {
+					vector[dest] = dvAdvert{Cost: *dvInfinity, Hops: route.Hops}
+					continue
+				}
+				if *dvSplitHorizon {
+					continue
+				}
+			}
+			vector[dest] = dvAdvert{Cost: route.Cost, Hops: route.Hops}
+		}
+		vectors[i] = vector
+	}
+	dv.mu.Unlock()
+
+	for i, neighbor := range neighbors {
+		dvProtocol.send(neighbor, Message{Source: node, Destination: neighbor, Type: MessageDistanceVector, Payload: vectors[i]})
+	}
+}
+
+func handleDistanceVector(node *Node, received Message) {
+	/*
+		handleDistanceVector met à jour les routes du nœud à partir du vecteur reçu d'un voisin
+		(algorithme de Bellman-Ford distribué).
+
+		Paramètres :
+			- node : le nœud qui a reçu le vecteur
+			- received : le message contenant le vecteur du voisin
+
+		Pour chaque destination annoncée, le coût par ce voisin est le coût annoncé plus le poids du lien,
+		borné par dvInfinity. La route est remplacée si ce coût est meilleur, et toujours mise à jour si
+		le voisin est déjà le next hop (même si le coût augmente). Si une route a changé, une mise à jour
+		déclenchée est programmée (voir triggerDistanceVector).
+
+		La fonction ne retourne rien.
+	*/
+	dv := node.DV
+	neighbor := received.Source
+	dv.mu.Lock()
+	weight, ok := dv.neighbors[neighbor]
+	if !ok {
+		// Le lien avec ce voisin n'existe plus : le vecteur est ignoré
+		dv.mu.Unlock()
+		return
+	}
+	now := clockNow()
+	changed := false
+	for dest, advert := range received.Payload.(DistanceVectorUpdate) {
+		if dest == node {
+			continue
+		}
+		cost := advert.Cost + weight
+		if cost > *dvInfinity {
+			cost = *dvInfinity
+		}
+		route, known := dv.routes[dest]
+		switch {
+		case !known:
+			if cost < *dvInfinity {
+				logDistanceVectorChange(node, dest, nil, cost, advert.Hops+1, neighbor, "nouvelle")
+				dv.routes[dest] = &dvRoute{Cost: cost, Hops: advert.Hops + 1, NextHop: neighbor, Refreshed: now}
+				changed = true
+			}
+		case route.NextHop == neighbor:
+			route.Refreshed = now
+			if route.Cost != cost || route.Hops != advert.Hops+1 {
+				logDistanceVectorChange(node, dest, route, cost, advert.Hops+1, neighbor, "mise à jour")
+				route.Cost = cost
+				route.Hops = advert.Hops + 1
+				changed = true
+			}
+		case cost < route.Cost:
+			logDistanceVectorChange(node, dest, route, cost, advert.Hops+1, neighbor, "meilleure")
+			route.Cost = cost
+			route.Hops = advert.Hops + 1
+			route.NextHop = neighbor
+			route.Refreshed = now
+			changed = true
+		}
+	}
+	if changed {
+		publishDistanceVector(node)
+	}
+	dv.mu.Unlock()
+
+	if changed {
+		triggerDistanceVector(node)
+	}
+}
+
+func triggerDistanceVector(node *Node) {
+	/*
+		triggerDistanceVector programme une mise à jour déclenchée du nœud après un court délai
+		(un dixième de dvPeriod). Les changements de route reçus pendant ce délai sont envoyés dans
+		la même mise à jour : comme le délai d'attente des mises à jour déclenchées de RIP, cela évite
+		que chaque vecteur reçu provoque un nouvel envoi à tous les voisins, ce qui sature les canaux
+		sur les grands graphes.
+
+		Paramètres :
+			- node : le nœud dont les routes ont changé
+
+		La fonction ne retourne rien.
+	*/
+	dv := node.DV
+	dv.mu.Lock()
+	if dv.triggered {
+		dv.mu.Unlock()
+		return
+	}
+	dv.triggered = true
+	dv.mu.Unlock()
+
+	afterFunc(*dvPeriod/10, func() {
+		dv.mu.Lock()
+		dv.triggered = false
+		dv.mu.Unlock()
+		advertiseDistanceVector(node)
+	})
+}
+
+func distanceVectorLinkDown(node *Node, neighbor *Node) {
+	/*
+		distanceVectorLinkDown signale au nœud que le lien vers un voisin a disparu : toutes les routes
+		passant par ce voisin deviennent infinies et une mise à jour déclenchée est envoyée.
+
+		Paramètres :
+			- node : le nœud qui a perdu le lien
+			- neighbor : le voisin qui n'est plus joignable directement
+
+		La fonction ne retourne rien.
+	*/
+	dvProtocol.markEvent()
+	dv := node.DV
+	dv.mu.Lock()
+	delete(dv.neighbors, neighbor)
+	for dest, route := range dv.routes {
+		if route.NextHop == neighbor && route.Cost < *dvInfinity {
+			logDistanceVectorChange(node, dest, route, *dvInfinity, route.Hops, neighbor, "lien perdu")
+			route.Cost = *dvInfinity
+		}
+	}
+	publishDistanceVector(node)
+	dv.mu.Unlock()
+	triggerDistanceVector(node)
+}
+
+func distanceVectorLinkUp(node *Node, neighbor *Node, weight int) {
+	/*
+		distanceVectorLinkUp signale au nœud qu'un lien vers un nouveau voisin est disponible. Le nœud
+		envoie aussitôt son vecteur, ce qui permet au voisin d'apprendre ses routes.
+
+		Paramètres :
+			- node : le nœud qui a obtenu le lien
+			- neighbor : le nouveau voisin
+			- weight : le poids du lien
+
+		La fonction ne retourne rien.
+	*/
+	dvProtocol.markEvent()
+	dv := node.DV
+	dv.mu.Lock()
+	dv.neighbors[neighbor] = weight
+	dv.mu.Unlock()
+	advertiseDistanceVector(node)
+}
+
+func distanceVectorLinkCost(node *Node, neighbor *Node, weight int) {
+	/*
+		distanceVectorLinkCost signale au nœud que le poids du lien vers un voisin a changé : le coût des
+		routes apprises par ce voisin change d'autant, et une mise à jour déclenchée est envoyée.
+
+		Paramètres :
+			- node : le nœud dont le lien a changé de poids
+			- neighbor : le voisin à l'autre extrémité du lien
+			- weight : le nouveau poids
+
+		Si le poids augmente, une route par un autre voisin peut devenir meilleure : elle sera choisie
+		à la prochaine annonce de ce voisin. Si le poids diminue, le voisin peut offrir de meilleures
+		routes : elles arrivent avec sa mise à jour déclenchée, puisque lui aussi est prévenu.
+
+		La fonction ne retourne rien.
+	*/
+	dvProtocol.markEvent()
+	dv := node.DV
+	dv.mu.Lock()
+	old, ok := dv.neighbors[neighbor]
+	if !ok {
+		dv.mu.Unlock()
+		return
+	}
+	dv.neighbors[neighbor] = weight
+	for dest, route := range dv.routes {
+		if route.NextHop == neighbor && dest != node && route.Cost < *dvInfinity {
+			cost := min(route.Cost-old+weight, *dvInfinity)
+			logDistanceVectorChange(node, dest, route, cost, route.Hops, neighbor, "poids du lien modifié")
+			route.Cost = cost
+		}
+	}
+	publishDistanceVector(node)
+	dv.mu.Unlock()
+	triggerDistanceVector(node)
+}
+
+func resetDistanceVector(node *Node) {
+	/*
+		resetDistanceVector efface l'état du protocole d'un routeur tombé en panne : il ne connaît plus
+		que lui-même et réapprendra ses routes par les vecteurs de ses voisins à son redémarrage.
+
+		Paramètres :
+			- node : le routeur en panne
+
+		La fonction ne retourne rien.
+	*/
+	dv := node.DV
+	dv.mu.Lock()
+	dv.routes = map[*Node]*dvRoute{node: {Cost: 0, NextHop: node, Refreshed: clockNow()}}
+	dv.neighbors = make(map[*Node]int)
+	publishDistanceVector(node)
+	dv.mu.Unlock()
+}
+
+func publishDistanceVector(node *Node) {
+	/*
+		publishDistanceVector reconstruit la table de routage du nœud à partir de ses routes apprises.
+		Le verrou node.DV.mu doit être tenu par l'appelant.
+
+		Paramètres :
+			- node : le nœud dont on publie la table
+
+		Le protocole ne connaît que le next hop, le coût et le nombre de sauts : le chemin complet des
+		entrées est inconnu (Parent est nil). Les routes de coût infini sont publiées comme injoignables.
+
+		La fonction ne retourne rien.
+	*/
+	now := clockNow()
+	previous := node.Table()
+	table := make(map[string]*RoutingEntry, len(node.DV.routes))
+	for dest, route := range node.DV.routes {
+		entry := &RoutingEntry{Source: node, Destination: dest, Cost: infiniteDistance, Updated: now}
+		if route.Cost < *dvInfinity {
+			entry.NextHop = route.NextHop
+			entry.Cost = route.Cost
+			entry.HopCount = route.Hops
+		}
+		if old, ok := previous[dest.Name]; ok && old.NextHop == entry.NextHop && old.Cost == entry.Cost {
+			entry.Updated = old.Updated
+		}
+		table[dest.Name] = entry
+	}
+	node.setRoutingTable(table)
+}
+
+func logDistanceVectorChange(node *Node, dest *Node, old *dvRoute, cost int, hops int, nextHop *Node, reason string) {
+	/*
+		logDistanceVectorChange compte un changement de route et l'affiche si l'option -dv-verbose
+		est utilisée, ce qui permet de suivre la convergence (et le comptage à l'infini).
+
+		Paramètres :
+			- node : le nœud dont la route change
+			- dest : la destination de la route
+			- old : l'ancienne route, nil si elle n'existait pas
+			- cost, hops, nextHop : la nouvelle route
+			- reason : la cause du changement
+
+		La fonction ne retourne rien.
+	*/
+	dvProtocol.routeChanged()
+	if !*dvVerbose {
+		return
+	}
+	before := "aucune"
+	if old != nil {
+		before = formatDistanceVectorCost(old.Cost) + " via " + old.NextHop.Name
+	}
+	fmt.Printf("[DV] %s -> %s : %s => %s via %s (%s)\n", node.Name, dest.Name, before, formatDistanceVectorCost(cost), nextHop.Name, reason)
+}
+
+func formatDistanceVectorCost(cost int) string {
+	if cost >= *dvInfinity {
+		return "infini"
+	}
+	return fmt.Sprint(cost)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+//**** EXPORT GRAPHVIZ (DOT) ****//
+
+var dotExportCount = 0 //numéro du prochain fichier écrit dans le dossier -dot
+
+func exportDOT(w io.Writer, g *Graph, root *Node) error {
+	/*
+		exportDOT écrit le graphe au format DOT de Graphviz (nœuds et poids des liens).
+
+		Paramètres :
+			- w : la destination de l'export
+			- g : le graphe à exporter
+			- root : le routeur dont on veut mettre en évidence l'arbre des plus courts chemins, ou nil
+
+		Chaque lien bidirectionnel n'est écrit qu'une seule fois. Si root n'est pas nil, l'arbre des
+		plus courts chemins est reconstruit en suivant les next_hop des tables de routage, de routeur
+		en routeur, depuis root jusqu'à chaque destination. Les liens de cet arbre sont dessinés en
+		rouge et root est coloré.
+
+		Retourne :
+			- Une erreur si l'écriture a échoué
+	*/
+	index := make(map[*Node]int, len(g.Nodes))
+	for i, node := range g.Nodes {
+		index[node] = i
+	}
+	tree := shortestPathTree(g, root)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "graph reseau {")
+	fmt.Fprintln(out, "\tnode [shape=circle];")
+	for _, node := range g.Nodes {
+		if node == root {
+			fmt.Fprintf(out, "\t%q [style=filled, fillcolor=lightcoral];\n", node.Name)
+		} else {
+			fmt.Fprintf(out, "\t%q;\n", node.Name)
+		}
+	}
+	for i, node := range g.Nodes {
+		for _, edge := range node.Edges {
+			// Le lien inverse est écrit par le nœud d'indice le plus petit
+			if j, ok := index[edge.To]; !ok || j < i {
+				continue
+			}
+			attrs := fmt.Sprintf("label=%d", edge.Weight)
+			if tree[[2]*Node{node, edge.To}] {
+				attrs += ", color=red, penwidth=2"
+			}
+			fmt.Fprintf(out, "\t%q -- %q [%s];\n", node.Name, edge.To.Name, attrs)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
+
+func shortestPathTree(g *Graph, root *Node) map[[2]*Node]bool {
+	/*
+		shortestPathTree retourne les liens de l'arbre des plus courts chemins de root, tels que
+		les tables de routage les décrivent.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- root : la racine de l'arbre, ou nil
+
+		Pour chaque destination on suit le next_hop de la table du routeur courant jusqu'à atteindre
+		la destination. Le parcours s'arrête si une destination est injoignable ou si les tables forment
+		une boucle (au plus len(g.Nodes) sauts).
+
+		Retourne :
+			- L'ensemble des liens de l'arbre, chaque lien étant présent dans les deux sens
+	*/
+	tree := make(map[[2]*Node]bool)
+	if root == nil {
+		return tree
+	}
+	for _, dest := range g.Nodes {
+		current := root
+		for hops := 0; current != dest && hops < len(g.Nodes); hops++ {
+			entry := current.Route(dest.Name)
+			if entry == nil || entry.NextHop == nil {
+				break
+			}
+			next := entry.NextHop
+			tree[[2]*Node{current, next}] = true
+			tree[[2]*Node{next, current}] = true
+			current = next
+		}
+	}
+	return tree
+}
+
+func writeDOTFile(path string, g *Graph, root *Node) error {
+	/*
+		writeDOTFile exporte le graphe au format DOT dans le fichier indiqué.
+
+		Paramètres :
+			- path : le chemin du fichier à créer (écrasé s'il existe)
+			- g : le graphe à exporter
+			- root : le routeur dont l'arbre des plus courts chemins est mis en évidence, ou nil
+
+		Retourne :
+			- Une erreur si le fichier n'a pas pu être écrit
+	*/
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := exportDOT(file, g, root); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func dumpDOT(g *Graph) {
+	/*
+		dumpDOT écrit un nouvel export DOT numéroté dans le dossier donné par l'option -dot,
+		si elle est utilisée. Les fichiers successifs (topologie_000.dot, topologie_001.dot, ...)
+		permettent de comparer l'évolution de la topologie après chaque ajout ou suppression de lien.
+
+		Paramètres :
+			- g : le graphe à exporter
+
+		La fonction ne retourne rien, une erreur d'écriture est seulement affichée.
+	*/
+	if *dotDir == "" {
+		return
+	}
+	if err := os.MkdirAll(*dotDir, 0o755); err != nil {
+		fmt.Println("Export DOT impossible :", err)
+		return
+	}
+	path := filepath.Join(*dotDir, fmt.Sprintf("topologie_%03d.dot", dotExportCount))
+	dotExportCount++
+	if err := writeDOTFile(path, g, findNode(g, *dotRoot)); err != nil {
+		fmt.Println("Export DOT impossible :", err)
+		return
+	}
+	fmt.Printf("Topologie exportée dans %s.\n", path)
+}
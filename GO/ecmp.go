@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//**** ROUTAGE ECMP (CHEMINS MULTIPLES DE MÊME COÛT) ****//
+
+// Couple routeur -> destination pour lequel la table donne plusieurs next hops
+type ecmpGroup struct {
+	Router      *Node
+	Destination *Node
+}
+
+// Utilisation d'un next hop d'un couple ecmpGroup
+type ecmpHopStats struct {
+	NextHop  *Node
+	Messages int64
+	Flows    int                 //nombre de flux différents, rempli par ecmpStatistics
+	flows    map[uint64]struct{} //flux transmis par ce next hop
+}
+
+// Répartition des messages d'un couple ecmpGroup entre ses next hops
+type ecmpGroupStats struct {
+	Group    ecmpGroup
+	Messages int64
+	Hops     []*ecmpHopStats //dans l'ordre de première utilisation
+}
+
+// Compteurs globaux //
+var flowCounter atomic.Uint64 //dernier identifiant de flux attribué à une demande Hello
+var ecmpMu sync.Mutex         //protège ecmpStats
+var ecmpStats = make(map[ecmpGroup]*ecmpGroupStats)
+
+func flowHash(node *Node, message Message) uint64 {
+	/*
+		flowHash calcule l'empreinte (FNV-1a) du flux d'un message sur un routeur, à partir du nom
+		du routeur, de la source et de la destination du message et de son identifiant de flux.
+
+		Paramètres :
+			- node : le routeur qui choisit le prochain saut
+			- message : le message à transmettre
+
+		Tous les messages d'un même flux ont la même empreinte sur un routeur et y suivent donc le
+		même chemin, ce qui évite de les remettre dans le désordre. Le nom du routeur rend les choix
+		des routeurs successifs indépendants : sans lui, tous les routeurs calculeraient la même
+		empreinte modulo leur nombre de next hops, et les flux envoyés sur le premier chemin par un
+		routeur le seraient aussi par les suivants (polarisation).
+
+		Retourne :
+			- L'empreinte du flux sur ce routeur
+	*/
+	h := fnv.New64a()
+	h.Write([]byte(node.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(message.Source.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(message.Destination.Name))
+	h.Write([]byte{0})
+	var flow [8]byte
+	binary.BigEndian.PutUint64(flow[:], message.Flow)
+	h.Write(flow[:])
+	// Les bits de poids faible de FNV-1a ne dépendent que des bits de poids faible des octets
+	// (le bit 0 est la parité de leur somme) : on les mélange avant le modulo (finaliseur de MurmurHash3)
+	sum := h.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	sum *= 0xc4ceb9fe1a85ec53
+	sum ^= sum >> 33
+	return sum
+}
+
+func nextHopFor(node *Node, entry *RoutingEntry, message Message) *Node {
+	/*
+		nextHopFor choisit le prochain saut d'un message parmi les next hops de même coût de l'entrée.
+
+		Paramètres :
+			- node : le routeur qui transmet le message
+			- entry : l'entrée de la table de node pour la destination du message (joignable)
+			- message : le message à transmettre
+
+		Le choix dépend seulement de l'empreinte du flux sur ce routeur (voir flowHash), chaque routeur du chemin
+		refaisant le choix parmi ses propres next hops. Avec l'option -ecmp=false, ou s'il n'y a qu'un
+		next hop (protocole à vecteur de distances notamment), c'est toujours NextHop. Chaque choix
+		entre plusieurs next hops est compté pour printECMPStatistics.
+
+		Retourne :
+			- Le prochain saut du message
+	*/
+	if !*ecmpFlag || len(entry.NextHops) < 2 {
+		return entry.NextHop
+	}
+	next := entry.NextHops[flowHash(node, message)%uint64(len(entry.NextHops))]
+	recordECMP(node, message, next)
+	return next
+}
+
+func recordECMP(node *Node, message Message, next *Node) {
+	/*
+		recordECMP compte un message transmis par l'un des next hops de même coût d'un routeur.
+
+		Paramètres :
+			- node : le routeur qui transmet le message
+			- message : le message transmis
+			- next : le next hop choisi
+
+		La fonction ne retourne rien.
+	*/
+	group := ecmpGroup{Router: node, Destination: message.Destination}
+	ecmpMu.Lock()
+	defer ecmpMu.Unlock()
+	stats := ecmpStats[group]
+	if stats == nil {
+		stats = &ecmpGroupStats{Group: group}
+		ecmpStats[group] = stats
+	}
+	stats.Messages++
+	var hop *ecmpHopStats
+	for _, h := range stats.Hops {
+		if h.NextHop == next {
+			hop = h
+			break
+		}
+	}
+	if hop == nil {
+		hop = &ecmpHopStats{NextHop: next, flows: make(map[uint64]struct{})}
+		stats.Hops = append(stats.Hops, hop)
+	}
+	hop.Messages++
+	hop.flows[message.Flow] = struct{}{}
+}
+
+func ecmpStatistics() []ecmpGroupStats {
+	/*
+		ecmpStatistics relève la répartition des messages entre les chemins de même coût.
+
+		Retourne :
+			- Une copie des compteurs de chaque couple routeur -> destination, du plus utilisé au
+			  moins utilisé
+	*/
+	ecmpMu.Lock()
+	stats := make([]ecmpGroupStats, 0, len(ecmpStats))
+	for _, s := range ecmpStats {
+		copied := *s
+		copied.Hops = make([]*ecmpHopStats, len(s.Hops))
+		for i, h := range s.Hops {
+			copied.Hops[i] = &ecmpHopStats{NextHop: h.NextHop, Messages: h.Messages, Flows: len(h.flows)}
+		}
+		stats = append(stats, copied)
+	}
+	ecmpMu.Unlock()
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Messages != b.Messages {
+			return a.Messages > b.Messages
+		}
+		if a.Group.Router != b.Group.Router {
+			return a.Group.Router.Name < b.Group.Router.Name
+		}
+		return a.Group.Destination.Name < b.Group.Destination.Name
+	})
+	return stats
+}
+
+func printECMPStatistics(top int) {
+	/*
+		printECMPStatistics affiche combien de messages ont été répartis entre des chemins de même coût
+		et, pour les couples routeur -> destination les plus utilisés, la part de chaque next hop.
+
+		Paramètres :
+			- top : le nombre maximal de couples détaillés
+
+		Rien n'est affiché si aucun message n'a eu le choix entre plusieurs chemins.
+
+		La fonction ne retourne rien.
+	*/
+	stats := ecmpStatistics()
+	if len(stats) == 0 {
+		return
+	}
+	var messages int64
+	for _, s := range stats {
+		messages += s.Messages
+	}
+	fmt.Printf("ECMP : %d messages répartis entre des chemins de même coût, sur %d couples routeur -> destination.\n", messages, len(stats))
+	for i, s := range stats {
+		if i == top {
+			fmt.Printf("  ... et %d autres\n", len(stats)-top)
+			break
+		}
+		hops := make([]string, len(s.Hops))
+		for j, h := range s.Hops {
+			hops[j] = fmt.Sprintf("%s %d (%.0f %%, %d flux)", h.NextHop.Name, h.Messages, 100*float64(h.Messages)/float64(s.Messages), h.Flows)
+		}
+		fmt.Printf("  %s -> %s : %s\n", s.Group.Router.Name, s.Group.Destination.Name, strings.Join(hops, ", "))
+	}
+}
+
+func formatNextHops(entry *RoutingEntry) string {
+	/*
+		formatNextHops écrit le ou les next hops d'une entrée joignable.
+
+		Paramètres :
+			- entry : l'entrée de la table de routage
+
+		Retourne :
+			- "R5", ou "R5 ou R7" s'il y a plusieurs chemins de même coût
+	*/
+	if len(entry.NextHops) < 2 {
+		return entry.NextHop.Name
+	}
+	names := make([]string, len(entry.NextHops))
+	for i, hop := range entry.NextHops {
+		names[i] = hop.Name
+	}
+	return strings.Join(names, " ou ")
+}
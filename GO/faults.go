@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+//**** PERTES, ALTÉRATIONS ET DUPLICATIONS DE MESSAGES SUR LES LIENS ****//
+
+// Générateur aléatoire des erreurs de transmission, partagé par les goroutines des routeurs
+type faultSource struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// Variables globales des erreurs de transmission //
+var faultRng *faultSource            //initialisé avec la graine de la simulation par buildGraph
+var lostMessages atomic.Int64        //messages perdus sur un lien (taux -loss)
+var corruptedMessages atomic.Int64   //messages altérés sur un lien, détruits par le routeur qui les reçoit
+var duplicatedMessages atomic.Int64  //messages remis deux fois par un lien
+var retransmittedHellos atomic.Int64 //Hello renvoyés faute de Hello Ack dans le délai -hello-retry
+
+func newFaultSource(seed int64) *faultSource {
+	/*
+		newFaultSource crée le générateur des erreurs de transmission.
+
+		Paramètres :
+			- seed : la graine de la simulation
+
+		Les erreurs ont leur propre générateur, pour que le tirage du graphe et du trafic ne change
+		pas quand les taux d'erreur changent.
+
+		Retourne :
+			- Le générateur, utilisable depuis plusieurs goroutines
+	*/
+	return &faultSource{r: rand.New(rand.NewSource(seed + 1))}
+}
+
+func (f *faultSource) chance(probability float64) bool {
+	/*
+		chance tire au sort un événement de probabilité donnée.
+
+		Paramètres :
+			- probability : la probabilité de l'événement, entre 0 et 1
+
+		Aucun tirage n'est fait pour une probabilité nulle : sans erreur configurée, la suite des
+		tirages (et donc la simulation) est la même qu'avant l'ajout des erreurs.
+
+		Retourne :
+			- true si l'événement se produit
+	*/
+	if probability <= 0 || f == nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.r.Float64() < probability
+}
+
+func linkFaults(from *Node, to *Node, message Message) []Message {
+	/*
+		linkFaults applique au message les erreurs de transmission du lien de from vers to.
+
+		Paramètres :
+			- from : le routeur qui envoie le message
+			- to : le voisin qui le reçoit
+			- message : le message
+
+		Le message est perdu avec la probabilité Loss du lien, marqué altéré (Corrupted) avec la
+		probabilité Corruption, et remis en deux exemplaires avec la probabilité Duplication. Le
+		double a sa propre copie de la route, que chaque exemplaire complète de son côté.
+
+		Retourne :
+			- Les exemplaires à transmettre : aucun si le message est perdu, deux s'il est dupliqué
+	*/
+	edge := findEdge(from, to)
+	if edge == nil {
+		return []Message{message}
+	}
+	if faultRng.chance(edge.Loss) {
+		lostMessages.Add(1)
+		return nil
+	}
+	if faultRng.chance(edge.Corruption) {
+		message.Corrupted = true
+	}
+	copies := []Message{message}
+	if faultRng.chance(edge.Duplication) {
+		duplicatedMessages.Add(1)
+		duplicate := message
+		duplicate.Route = append([]Hop(nil), message.Route...)
+		copies = append(copies, duplicate)
+	}
+	return copies
+}
+
+func printFaultStatistics() {
+	/*
+		printFaultStatistics affiche le nombre de messages perdus, altérés et dupliqués sur les liens
+		et le nombre de Hello retransmis.
+
+		Rien n'est affiché si aucune erreur de transmission ne s'est produite.
+
+		La fonction ne retourne rien.
+	*/
+	lost, corrupted, duplicated := lostMessages.Load(), corruptedMessages.Load(), duplicatedMessages.Load()
+	if lost+corrupted+duplicated == 0 {
+		return
+	}
+	fmt.Printf("Erreurs de transmission : %d messages perdus, %d altérés, %d dupliqués ; %d Hello retransmis.\n",
+		lost, corrupted, duplicated, retransmittedHellos.Load())
+}
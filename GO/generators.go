@@ -0,0 +1,593 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+//**** GÉNÉRATEURS DE TOPOLOGIES ****//
+
+// Formes de graphe proposées par l'option -shape, en plus du graphe aléatoire d'initRandomGraph
+const (
+	shapeRandom   = "random"   //graphe aléatoire d'au plus -i interfaces par routeur (initRandomGraph)
+	shapeRing     = "ring"     //anneau
+	shapeGrid     = "grid"     //grille, ligne par ligne
+	shapeStar     = "star"     //étoile autour de R1
+	shapeTree     = "tree"     //arbre binaire de racine R1
+	shapeFatTree  = "fat-tree" //fat-tree de centre de données à k ports par commutateur
+	shapeWaxman   = "waxman"   //graphe géométrique de Waxman
+	shapeBarabasi = "ba"       //graphe sans échelle de Barabási-Albert
+)
+
+// Lois de tirage des poids des liens proposées par l'option -weights
+const (
+	weightsConstant    = "constant"    //constant:w
+	weightsUniform     = "uniform"     //uniform:min:max
+	weightsNormal      = "normal"      //normal:moyenne:écart-type
+	weightsExponential = "exponential" //exponential:moyenne
+)
+
+// Forme de graphe demandée, avec ses paramètres (voir parseShape)
+type graphShape struct {
+	Name   string
+	Params []float64
+}
+
+// Loi de tirage des poids des liens générés (voir parseWeights)
+type weightDistribution struct {
+	Name   string
+	Params []float64
+}
+
+// Générateur d'une forme de graphe
+type graphGenerator struct {
+	Name        string
+	Description string
+	Defaults    []float64 //valeurs par défaut des paramètres de la forme
+	Sized       bool      //true si le nombre de routeurs est donné par -n
+	Build       func(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error)
+}
+
+// Poids des liens du graphe aléatoire d'origine, entre 1 et weightRange
+var defaultWeights = weightDistribution{Name: weightsUniform, Params: []float64{1, weightRange}}
+
+// Générateurs des formes de l'option -shape, autres que random
+var graphGenerators = []graphGenerator{
+	{shapeRing, "anneau de n routeurs", nil, true, generateRing},
+	{shapeGrid, "grille de n routeurs, ligne par ligne (⌈√n⌉ colonnes)", nil, true, generateGrid},
+	{shapeStar, "étoile de n routeurs autour de R1", nil, true, generateStar},
+	{shapeTree, "arbre binaire de n routeurs de racine R1", nil, true, generateTree},
+	{shapeFatTree, "fat-tree:k, k pods de commutateurs à k ports (k pair, 5k²/4 routeurs)", []float64{4}, false, generateFatTree},
+	{shapeWaxman, "waxman:alpha:beta, n routeurs placés au hasard, reliés avec la probabilité alpha·exp(-d/(beta·√2))", []float64{0.4, 0.2}, true, generateWaxman},
+	{shapeBarabasi, "ba:m, n routeurs ajoutés un à un, chacun relié à m routeurs choisis selon leur degré", []float64{2}, true, generateBarabasiAlbert},
+}
+
+func parseSpec(spec string) (string, []float64, error) {
+	/*
+		parseSpec découpe une option de la forme "nom:p1:p2" en son nom et ses paramètres numériques.
+
+		Paramètres :
+			- spec : la valeur de l'option
+
+		Retourne :
+			- Le nom et les paramètres
+			- Une erreur si un paramètre n'est pas un nombre
+	*/
+	fields := strings.Split(spec, ":")
+	params := make([]float64, 0, len(fields)-1)
+	for _, field := range fields[1:] {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("paramètre invalide %q dans %q", field, spec)
+		}
+		params = append(params, value)
+	}
+	return fields[0], params, nil
+}
+
+func findGenerator(name string) *graphGenerator {
+	/*
+		findGenerator cherche le générateur d'une forme de graphe.
+
+		Paramètres :
+			- name : le nom de la forme
+
+		Retourne :
+			- Le générateur, nil si la forme est inconnue ou si c'est la forme random
+	*/
+	for i := range graphGenerators {
+		if graphGenerators[i].Name == name {
+			return &graphGenerators[i]
+		}
+	}
+	return nil
+}
+
+func parseShape(spec string) (graphShape, error) {
+	/*
+		parseShape lit l'option -shape : "random", "ring", "grid", "star", "tree", "fat-tree[:k]",
+		"waxman[:alpha:beta]" ou "ba[:m]". Les paramètres absents prennent leur valeur par défaut.
+
+		Paramètres :
+			- spec : la valeur de l'option
+
+		Retourne :
+			- La forme, avec tous ses paramètres
+			- Une erreur si la forme est inconnue ou si ses paramètres sont invalides
+	*/
+	name, params, err := parseSpec(spec)
+	if err != nil {
+		return graphShape{}, err
+	}
+	if name == shapeRandom {
+		if len(params) > 0 {
+			return graphShape{}, fmt.Errorf("la forme random n'a pas de paramètre (utiliser -n et -i)")
+		}
+		return graphShape{Name: name}, nil
+	}
+	generator := findGenerator(name)
+	if generator == nil {
+		names := []string{shapeRandom}
+		for _, g := range graphGenerators {
+			names = append(names, g.Name)
+		}
+		return graphShape{}, fmt.Errorf("forme de graphe inconnue %q (formes : %s)", name, strings.Join(names, ", "))
+	}
+	if len(params) > len(generator.Defaults) {
+		return graphShape{}, fmt.Errorf("trop de paramètres pour la forme %s (%s)", name, generator.Description)
+	}
+	params = append(params, generator.Defaults[len(params):]...)
+	return graphShape{Name: name, Params: params}, nil
+}
+
+func (shape graphShape) sized() bool {
+	/*
+		sized indique si le nombre de routeurs de la forme est donné par -n.
+
+		Retourne :
+			- false pour le fat-tree, dont la taille dépend de k
+	*/
+	generator := findGenerator(shape.Name)
+	return generator == nil || generator.Sized
+}
+
+func parseWeights(spec string) (weightDistribution, error) {
+	/*
+		parseWeights lit l'option -weights : "constant:w", "uniform:min:max", "normal:moyenne:écart-type"
+		ou "exponential:moyenne".
+
+		Paramètres :
+			- spec : la valeur de l'option
+
+		Retourne :
+			- La loi de tirage des poids
+			- Une erreur si la loi est inconnue ou si ses paramètres sont invalides
+	*/
+	name, params, err := parseSpec(spec)
+	if err != nil {
+		return weightDistribution{}, err
+	}
+	expected := map[string]int{weightsConstant: 1, weightsUniform: 2, weightsNormal: 2, weightsExponential: 1}
+	count, ok := expected[name]
+	switch {
+	case !ok:
+		return weightDistribution{}, fmt.Errorf("loi de poids inconnue %q (constant:w, uniform:min:max, normal:moyenne:écart-type, exponential:moyenne)", name)
+	case len(params) != count:
+		return weightDistribution{}, fmt.Errorf("la loi de poids %s attend %d paramètres", name, count)
+	case params[0] < 1:
+		return weightDistribution{}, fmt.Errorf("loi de poids %q : les poids doivent être au moins 1", spec)
+	case name == weightsUniform && (params[1] < params[0] || params[0] != math.Trunc(params[0]) || params[1] != math.Trunc(params[1])):
+		return weightDistribution{}, fmt.Errorf("loi de poids %q : min et max doivent être des entiers avec min <= max", spec)
+	case name == weightsNormal && params[1] < 0:
+		return weightDistribution{}, fmt.Errorf("loi de poids %q : l'écart-type doit être positif", spec)
+	}
+	return weightDistribution{Name: name, Params: params}, nil
+}
+
+func (weights weightDistribution) draw(r *rand.Rand) int {
+	/*
+		draw tire le poids d'un lien.
+
+		Paramètres :
+			- r : le générateur aléatoire du graphe
+
+		La loi uniforme fait le même tirage que le graphe aléatoire d'origine (r.Intn), pour qu'une
+		graine redonne le même graphe avec les poids par défaut.
+
+		Retourne :
+			- Le poids, arrondi à l'entier le plus proche et au moins 1
+	*/
+	var weight float64
+	switch weights.Name {
+	case weightsConstant:
+		return int(math.Round(weights.Params[0]))
+	case weightsUniform:
+		low, high := int(weights.Params[0]), int(weights.Params[1])
+		return low + r.Intn(high-low+1)
+	case weightsNormal:
+		weight = weights.Params[0] + weights.Params[1]*r.NormFloat64()
+	case weightsExponential:
+		weight = weights.Params[0] * r.ExpFloat64()
+	}
+	return max(1, int(math.Round(weight)))
+}
+
+func (weights weightDistribution) String() string {
+	/*
+		String écrit la loi sous la forme de l'option -weights.
+
+		Retourne :
+			- La loi et ses paramètres, par exemple "uniform:1:20"
+	*/
+	parts := []string{weights.Name}
+	for _, param := range weights.Params {
+		parts = append(parts, strconv.FormatFloat(param, 'g', -1, 64))
+	}
+	return strings.Join(parts, ":")
+}
+
+func generateGraph(shape graphShape, n int, weights weightDistribution, r *rand.Rand) (Graph, error) {
+	/*
+		generateGraph génère un graphe d'une forme donnée (autre que random).
+
+		Paramètres :
+			- shape : la forme et ses paramètres, lus par parseShape
+			- n : le nombre de routeurs, ignoré par le fat-tree
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Tous les liens sont créés dans les deux sens avec le même poids (voir linkNodes), et les
+		générateurs qui peuvent produire plusieurs parties (Waxman) les relient ensuite : le graphe
+		est toujours connexe et symétrique.
+
+		Retourne :
+			- Le graphe
+			- Une erreur si la taille ou les paramètres ne conviennent pas à la forme
+	*/
+	generator := findGenerator(shape.Name)
+	if generator == nil {
+		return Graph{}, fmt.Errorf("forme de graphe inconnue %q", shape.Name)
+	}
+	if generator.Sized && n < 3 {
+		return Graph{}, fmt.Errorf("la forme %s demande au moins 3 routeurs (-n)", shape.Name)
+	}
+	nodes, err := generator.Build(n, shape.Params, weights, r)
+	if err != nil {
+		return Graph{}, fmt.Errorf("forme %s : %w", shape.Name, err)
+	}
+	return Graph{Nodes: nodes}, nil
+}
+
+func newRouters(n int) []*Node {
+	/*
+		newRouters crée n routeurs sans lien, nommés R1 à Rn.
+
+		Paramètres :
+			- n : le nombre de routeurs
+
+		Retourne :
+			- Les routeurs, avec leur canal de messages
+	*/
+	nodes := make([]*Node, n)
+	for i := range nodes {
+		nodes[i] = &Node{Name: fmt.Sprintf("R%d", i+1), Channel: make(chan Message)}
+	}
+	return nodes
+}
+
+func linkNodes(nodeA *Node, nodeB *Node, weight int) {
+	/*
+		linkNodes relie deux routeurs pendant la génération d'un graphe, par un lien dans chaque sens
+		de même poids.
+
+		Paramètres :
+			- nodeA : une extrémité du lien
+			- nodeB : l'autre extrémité
+			- weight : le poids du lien
+
+		La fonction ne retourne rien.
+	*/
+	nodeA.Edges = append(nodeA.Edges, newEdge(nodeB, weight))
+	nodeB.Edges = append(nodeB.Edges, newEdge(nodeA, weight))
+}
+
+func connectComponents(nodes []*Node, maxEdges int, weights weightDistribution, r *rand.Rand) (int, error) {
+	/*
+		connectComponents relie les parties d'un graphe généré qui n'est pas connexe : chaque partie
+		est reliée, par un lien entre deux routeurs tirés au hasard, à l'ensemble des parties qui la
+		précèdent, déjà reliées entre elles.
+
+		Paramètres :
+			- nodes : les routeurs du graphe
+			- maxEdges : le nombre maximal de liens par routeur, 0 pour ne pas le limiter
+			- weights : la loi de tirage des poids des liens ajoutés
+			- r : le générateur aléatoire du graphe
+
+		Seuls les routeurs qui ont encore une interface libre sont tirés. Aucun tirage n'est fait si
+		le graphe est déjà connexe.
+
+		Retourne :
+			- Le nombre de liens ajoutés
+			- Une erreur si une partie ne peut pas être reliée sans dépasser maxEdges liens
+	*/
+	component := make(map[*Node]int, len(nodes))
+	var parts [][]*Node
+	for _, start := range nodes {
+		if _, seen := component[start]; seen {
+			continue
+		}
+		part := []*Node{start}
+		component[start] = len(parts)
+		for i := 0; i < len(part); i++ {
+			for _, edge := range part[i].Edges {
+				if _, seen := component[edge.To]; !seen {
+					component[edge.To] = len(parts)
+					part = append(part, edge.To)
+				}
+			}
+		}
+		parts = append(parts, part)
+	}
+	connected := parts[0]
+	for _, part := range parts[1:] {
+		nodeA, nodeB := freeRouter(connected, maxEdges, r), freeRouter(part, maxEdges, r)
+		if nodeA == nil || nodeB == nil {
+			return 0, fmt.Errorf("impossible de relier les parties du graphe sans dépasser %d interfaces par routeur", maxEdges)
+		}
+		linkNodes(nodeA, nodeB, weights.draw(r))
+		connected = append(connected, part...)
+	}
+	return len(parts) - 1, nil
+}
+
+func freeRouter(nodes []*Node, maxEdges int, r *rand.Rand) *Node {
+	/*
+		freeRouter tire au hasard un routeur qui a encore une interface libre.
+
+		Paramètres :
+			- nodes : les routeurs parmi lesquels tirer
+			- maxEdges : le nombre maximal de liens par routeur, 0 pour ne pas le limiter
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Le routeur tiré, ou nil si tous les routeurs ont maxEdges liens
+	*/
+	if maxEdges <= 0 {
+		return nodes[r.Intn(len(nodes))]
+	}
+	var free []*Node
+	for _, node := range nodes {
+		if len(node.Edges) < maxEdges {
+			free = append(free, node)
+		}
+	}
+	if len(free) == 0 {
+		return nil
+	}
+	return free[r.Intn(len(free))]
+}
+
+func generateRing(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateRing relie chaque routeur au suivant, et le dernier au premier.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- params : aucun paramètre
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Les routeurs de l'anneau, chacun avec deux liens
+			- Aucune erreur
+	*/
+	nodes := newRouters(n)
+	for i, node := range nodes {
+		linkNodes(node, nodes[(i+1)%n], weights.draw(r))
+	}
+	return nodes, nil
+}
+
+func generateGrid(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateGrid place les routeurs sur une grille de ⌈√n⌉ colonnes, ligne par ligne, et relie
+		chaque routeur à ses voisins de droite et du dessous. La dernière ligne peut être incomplète.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- params : aucun paramètre
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Les routeurs de la grille, de 1 à 4 liens chacun
+			- Aucune erreur
+	*/
+	nodes := newRouters(n)
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	for i, node := range nodes {
+		if (i+1)%cols != 0 && i+1 < n {
+			linkNodes(node, nodes[i+1], weights.draw(r))
+		}
+		if i+cols < n {
+			linkNodes(node, nodes[i+cols], weights.draw(r))
+		}
+	}
+	return nodes, nil
+}
+
+func generateStar(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateStar relie tous les routeurs à R1.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- params : aucun paramètre
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Les routeurs de l'étoile, R1 ayant n-1 liens
+			- Aucune erreur
+	*/
+	nodes := newRouters(n)
+	for _, node := range nodes[1:] {
+		linkNodes(nodes[0], node, weights.draw(r))
+	}
+	return nodes, nil
+}
+
+func generateTree(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateTree construit un arbre binaire complet, rempli niveau par niveau : le parent du
+		routeur d'indice i est celui d'indice (i-1)/2.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- params : aucun paramètre
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Les routeurs de l'arbre, de racine R1
+			- Aucune erreur
+	*/
+	nodes := newRouters(n)
+	for i := 1; i < n; i++ {
+		linkNodes(nodes[(i-1)/2], nodes[i], weights.draw(r))
+	}
+	return nodes, nil
+}
+
+func generateFatTree(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateFatTree construit le fat-tree d'un centre de données à commutateurs de k ports :
+		(k/2)² commutateurs de cœur, puis k pods de k/2 commutateurs d'agrégation et k/2 commutateurs
+		d'accès. Dans un pod, chaque commutateur d'accès est relié à chaque commutateur d'agrégation ;
+		le j-ième commutateur d'agrégation de chaque pod est relié aux k/2 commutateurs de cœur du
+		groupe j. Les machines, reliées aux commutateurs d'accès, ne sont pas simulées.
+
+		Paramètres :
+			- n : ignoré, la taille dépend de k
+			- params : k, nombre pair de ports par commutateur
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Les routeurs du fat-tree, le cœur d'abord puis pod par pod l'agrégation et l'accès
+			- Une erreur si k n'est pas un entier pair d'au moins 2
+	*/
+	k := int(params[0])
+	if float64(k) != params[0] || k < 2 || k%2 != 0 {
+		return nil, fmt.Errorf("k doit être un entier pair d'au moins 2")
+	}
+	half := k / 2
+	nodes := newRouters(half*half + k*k)
+	core := nodes[:half*half]
+	for pod := 0; pod < k; pod++ {
+		aggregation := nodes[half*half+pod*k : half*half+pod*k+half]
+		access := nodes[half*half+pod*k+half : half*half+(pod+1)*k]
+		for j, agg := range aggregation {
+			for _, edge := range access {
+				linkNodes(agg, edge, weights.draw(r))
+			}
+			for _, c := range core[j*half : (j+1)*half] {
+				linkNodes(agg, c, weights.draw(r))
+			}
+		}
+	}
+	return nodes, nil
+}
+
+func generateWaxman(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateWaxman place les routeurs au hasard dans un carré de côté 1 et relie chaque paire
+		avec la probabilité alpha·exp(-d/(beta·L)), où d est la distance entre les deux routeurs et
+		L = √2 la plus grande distance possible : les liens courts sont plus probables que les longs.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- params : alpha (densité des liens, entre 0 et 1) et beta (portée des liens, positive)
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Les parties isolées par le tirage sont ensuite reliées entre elles (voir connectComponents).
+
+		Retourne :
+			- Les routeurs du graphe
+			- Une erreur si alpha ou beta sont hors de leur intervalle
+	*/
+	alpha, beta := params[0], params[1]
+	if alpha <= 0 || alpha > 1 || beta <= 0 {
+		return nil, fmt.Errorf("alpha doit être dans ]0, 1] et beta positif")
+	}
+	nodes := newRouters(n)
+	x, y := make([]float64, n), make([]float64, n)
+	for i := range nodes {
+		x[i], y[i] = r.Float64(), r.Float64()
+	}
+	for i := range nodes {
+		for j := i + 1; j < n; j++ {
+			d := math.Hypot(x[i]-x[j], y[i]-y[j])
+			if r.Float64() < alpha*math.Exp(-d/(beta*math.Sqrt2)) {
+				linkNodes(nodes[i], nodes[j], weights.draw(r))
+			}
+		}
+	}
+	if _, err := connectComponents(nodes, 0, weights, r); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func generateBarabasiAlbert(n int, params []float64, weights weightDistribution, r *rand.Rand) ([]*Node, error) {
+	/*
+		generateBarabasiAlbert construit un graphe sans échelle par attachement préférentiel : les
+		m+1 premiers routeurs sont reliés deux à deux, puis chaque nouveau routeur est relié à m
+		routeurs distincts, chacun choisi avec une probabilité proportionnelle à son nombre de liens.
+		Quelques routeurs concentrent ainsi beaucoup de liens, comme les grands nœuds d'Internet.
+
+		Paramètres :
+			- n : le nombre de routeurs
+			- params : m, nombre de liens de chaque nouveau routeur
+			- weights : la loi de tirage des poids des liens
+			- r : le générateur aléatoire du graphe
+
+		Retourne :
+			- Les routeurs du graphe
+			- Une erreur si m n'est pas un entier entre 1 et n-1
+	*/
+	m := int(params[0])
+	if float64(m) != params[0] || m < 1 || m >= n {
+		return nil, fmt.Errorf("m doit être un entier entre 1 et %d", n-1)
+	}
+	nodes := newRouters(n)
+	// Chaque routeur apparaît dans ends autant de fois qu'il a de liens : un tirage uniforme dans
+	// ends choisit donc un routeur proportionnellement à son degré
+	var ends []*Node
+	for i := 0; i <= m; i++ {
+		for j := i + 1; j <= m; j++ {
+			linkNodes(nodes[i], nodes[j], weights.draw(r))
+			ends = append(ends, nodes[i], nodes[j])
+		}
+	}
+	for _, node := range nodes[m+1:] {
+		chosen := make(map[*Node]bool, m)
+		targets := make([]*Node, 0, m)
+		for len(targets) < m {
+			target := ends[r.Intn(len(ends))]
+			if !chosen[target] {
+				chosen[target] = true
+				targets = append(targets, target)
+			}
+		}
+		for _, target := range targets {
+			linkNodes(node, target, weights.draw(r))
+			ends = append(ends, node, target)
+		}
+	}
+	return nodes, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func checkConnected(g *Graph) error {
+	/*
+		checkConnected vérifie que tous les routeurs d'un graphe généré sont joignables depuis R1.
+
+		Paramètres :
+			- g : le graphe généré
+
+		Retourne :
+			- Une erreur si le graphe n'est pas connexe
+	*/
+	reached := map[*Node]bool{g.Nodes[0]: true}
+	queue := []*Node{g.Nodes[0]}
+	for i := 0; i < len(queue); i++ {
+		for _, edge := range queue[i].Edges {
+			if !reached[edge.To] {
+				reached[edge.To] = true
+				queue = append(queue, edge.To)
+			}
+		}
+	}
+	if len(reached) != len(g.Nodes) {
+		return fmt.Errorf("graphe non connexe : %d routeurs joignables depuis R1 sur %d", len(reached), len(g.Nodes))
+	}
+	return nil
+}
+
+func TestGenerateGraph(t *testing.T) {
+	/*
+		TestGenerateGraph génère chaque forme pour plusieurs tailles et graines et vérifie que le
+		graphe respecte les règles de Validate et qu'il est connexe, et que sa taille est celle de la forme : n liens pour un
+		anneau, n-1 pour une étoile ou un arbre, 5k²/4 routeurs et k³/2 liens pour un fat-tree, et m
+		liens vers les routeurs précédents pour chaque nouveau routeur de Barabási-Albert.
+	*/
+	tests := []struct {
+		spec  string
+		links func(n int) int //nombre de liens attendu, nil s'il dépend du tirage
+	}{
+		{"ring", func(n int) int { return n }},
+		{"grid", nil},
+		{"star", func(n int) int { return n - 1 }},
+		{"tree", func(n int) int { return n - 1 }},
+		{"fat-tree:2", func(int) int { return 4 }},
+		{"fat-tree:4", func(int) int { return 32 }},
+		{"fat-tree:6", func(int) int { return 108 }},
+		{"waxman", nil},
+		{"waxman:0.05:0.05", nil},
+		{"ba", func(n int) int { return 3 + (n-3)*2 }},
+		{"ba:4", func(n int) int { return 10 + (n-5)*4 }},
+	}
+	defer func(edges int) { maxEdges = edges }(maxEdges)
+	maxEdges = 0 //le nombre d'interfaces d'une forme est son degré maximal
+	for _, test := range tests {
+		shape, err := parseShape(test.spec)
+		if err != nil {
+			t.Fatalf("forme %s refusée : %v", test.spec, err)
+		}
+		for _, n := range []int{5, 16, 50} {
+			for seed := int64(1); seed <= 3; seed++ {
+				t.Run(fmt.Sprintf("%s/n=%d/seed=%d", test.spec, n, seed), func(t *testing.T) {
+					graph, err := generateGraph(shape, n, defaultWeights, rand.New(rand.NewSource(seed)))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if violations := Validate(&graph); violations != nil {
+						t.Fatalf("%d violations : %v", len(violations), violations)
+					}
+					if err := checkConnected(&graph); err != nil {
+						t.Fatal(err)
+					}
+					routers, links := len(graph.Nodes), 0
+					for _, node := range graph.Nodes {
+						links += len(node.Edges)
+					}
+					links /= 2
+					if shape.Name == shapeFatTree {
+						k := int(shape.Params[0])
+						if routers != 5*k*k/4 {
+							t.Errorf("%d routeurs ; attendu 5k²/4 = %d", routers, 5*k*k/4)
+						}
+					} else if routers != n {
+						t.Errorf("%d routeurs ; attendu %d", routers, n)
+					}
+					if test.links != nil && links != test.links(n) {
+						t.Errorf("%d liens ; attendu %d", links, test.links(n))
+					}
+					if shape.Name == shapeBarabasi {
+						m := int(shape.Params[0])
+						position := make(map[*Node]int, routers)
+						for i, node := range graph.Nodes {
+							position[node] = i
+						}
+						for i, node := range graph.Nodes[m+1:] {
+							earlier := 0
+							for _, edge := range node.Edges {
+								if position[edge.To] < m+1+i {
+									earlier++
+								}
+							}
+							if earlier != m {
+								t.Errorf("%s relié à %d routeurs précédents ; attendu m = %d", node.Name, earlier, m)
+							}
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestGenerateGraphErrors(t *testing.T) {
+	/*
+		TestGenerateGraphErrors vérifie que les tailles et les paramètres qui ne conviennent pas à une
+		forme sont refusés.
+	*/
+	tests := []struct {
+		spec string
+		n    int
+		want string
+	}{
+		{"ring", 2, "au moins 3 routeurs"},
+		{"fat-tree:3", 0, "k doit être un entier pair"},
+		{"fat-tree:0", 0, "k doit être un entier pair"},
+		{"fat-tree:2.5", 0, "k doit être un entier pair"},
+		{"waxman:0:0.2", 10, "alpha doit être dans ]0, 1]"},
+		{"waxman:1.5:0.2", 10, "alpha doit être dans ]0, 1]"},
+		{"waxman:0.4:0", 10, "beta positif"},
+		{"ba:0", 10, "m doit être un entier entre 1 et 9"},
+		{"ba:10", 10, "m doit être un entier entre 1 et 9"},
+		{"ba:12", 10, "m doit être un entier entre 1 et 9"},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			shape, err := parseShape(test.spec)
+			if err != nil {
+				t.Fatalf("forme %s refusée à la lecture : %v", test.spec, err)
+			}
+			_, err = generateGraph(shape, test.n, defaultWeights, rand.New(rand.NewSource(1)))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("erreur = %v ; attendu une erreur contenant %q", err, test.want)
+			}
+		})
+	}
+}
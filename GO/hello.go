@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//**** SUIVI DES ÉCHANGES HELLO / HELLO ACK ****//
+
+// Demande Hello envoyée par Source à Destination, terminée quand Source reçoit le Hello Ack
+// ou la notification d'un message expiré en transit
+type helloRequest struct {
+	Source      *Node
+	Destination *Node
+	Flow        uint64        //identifiant du flux du Hello et de son Hello Ack, propre à chaque demande (voir nextHopFor)
+	Path        []Hop         //route suivie par le premier Hello arrivé, enregistrée par la destination (lisible une fois terminée)
+	Failure     string        //cause de l'échec, vide si le Hello Ack a été reçu (lisible une fois terminée)
+	Sent        time.Time     //date d'envoi du premier Hello
+	Acked       time.Time     //date de réception du Hello Ack (lisible une fois terminée)
+	done        chan struct{} //fermé à la réception du Hello Ack ou de la notification d'échec
+	once        sync.Once
+	pathMu      sync.Mutex   //protège Path, que plusieurs exemplaires du Hello peuvent vouloir enregistrer
+	retries     atomic.Int32 //nombre de retransmissions du Hello
+}
+
+func newHelloRequest(nodeSrc *Node, nodeDst *Node) *helloRequest {
+	/*
+		newHelloRequest crée le suivi d'un échange Hello entre deux nœuds.
+
+		Paramètres :
+			- nodeSrc : le nœud qui envoie le Hello
+			- nodeDst : le nœud qui doit répondre par un Hello Ack
+
+		Retourne :
+			- La demande, pas encore acquittée
+	*/
+	return &helloRequest{Source: nodeSrc, Destination: nodeDst, Flow: flowCounter.Add(1), done: make(chan struct{})}
+}
+
+func (request *helloRequest) acknowledge() {
+	/*
+		acknowledge marque la demande comme acquittée et note la date de réception du Hello Ack.
+		Un Hello Ack reçu en double est ignoré.
+
+		La fonction ne retourne rien.
+	*/
+	request.once.Do(func() {
+		request.Acked = clockNow()
+		close(request.done)
+	})
+}
+
+func (request *helloRequest) fail(reason string) {
+	/*
+		fail termine la demande sans Hello Ack, par exemple quand le Hello ou le Hello Ack a expiré
+		en transit. Une demande déjà terminée n'est pas modifiée.
+
+		Paramètres :
+			- reason : la cause de l'échec, affichée par reportMissingAcks
+
+		La fonction ne retourne rien.
+	*/
+	request.once.Do(func() {
+		request.Failure = reason
+		close(request.done)
+	})
+}
+
+func (request *helloRequest) recordPath(route []Hop) {
+	/*
+		recordPath enregistre la route suivie par le Hello jusqu'à la destination. Seule la route du
+		premier exemplaire arrivé est gardée : un Hello retransmis ou dupliqué par un lien ne la
+		remplace pas.
+
+		Paramètres :
+			- route : la route du Hello reçu par la destination
+
+		La fonction ne retourne rien.
+	*/
+	request.pathMu.Lock()
+	defer request.pathMu.Unlock()
+	if request.Path == nil {
+		request.Path = route
+	}
+}
+
+func (request *helloRequest) retransmit() {
+	/*
+		retransmit programme le renvoi du Hello si la demande n'est pas terminée après le délai
+		-hello-retry. Si le Hello a déjà été renvoyé -hello-retries fois, la demande échoue à la fin
+		de ce délai, sans attendre le délai -hello-timeout de la série. Une retransmission encore
+		programmée à l'arrêt du réseau est annulée (voir trafficTracker).
+
+		La fonction ne retourne rien.
+	*/
+	if *helloRetry <= 0 {
+		return
+	}
+	afterFunc(*helloRetry, func() {
+		if request.finished() {
+			return
+		}
+		if n := request.retransmissions(); n >= *helloRetries {
+			request.fail(fmt.Sprintf("pas de Hello Ack après %d retransmissions", n))
+			return
+		}
+		request.retries.Add(1)
+		retransmittedHellos.Add(1)
+		sendHello(request)
+	})
+}
+
+func (request *helloRequest) retransmissions() int {
+	/*
+		retransmissions retourne le nombre de fois où le Hello a été renvoyé.
+
+		Retourne :
+			- Le nombre de retransmissions
+	*/
+	return int(request.retries.Load())
+}
+
+func helloRetransmissions(requests []*helloRequest) int {
+	/*
+		helloRetransmissions compte les retransmissions de Hello d'un ensemble de demandes.
+
+		Paramètres :
+			- requests : les demandes
+
+		Retourne :
+			- Le nombre total de retransmissions
+	*/
+	total := 0
+	for _, request := range requests {
+		total += request.retransmissions()
+	}
+	return total
+}
+
+func helloRound(requests []*helloRequest, timeout time.Duration) []*helloRequest {
+	/*
+		helloRound envoie un Hello pour chaque demande et attend les Hello Ack correspondants.
+
+		Paramètres :
+			- requests : les demandes à envoyer
+			- timeout : la durée maximale d'attente de l'ensemble des Hello Ack
+
+		Chaque Hello transporte sa demande, que le Hello Ack rapporte à la source : l'attente ne dépend
+		donc que des échanges de cette série, et un message perdu ne bloque pas le programme au-delà du
+		délai. Une demande dont le message a expiré en transit se termine dès la réception de la
+		notification. Les demandes sans réponse sont affichées avec leur cause et terminées, ce qui
+		arrête leurs retransmissions, puis le nombre de Hello retransmis. En mode des, le délai est mesuré sur l'horloge virtuelle.
+
+		Retourne :
+			- Les demandes qui n'ont pas été acquittées avant le délai
+	*/
+	if engine != nil {
+		waitHelloEvents(requests, timeout)
+	} else {
+		waitHelloGoroutines(requests, timeout)
+	}
+
+	var missing []*helloRequest
+	var reasons []string
+	for _, request := range requests {
+		// Sans effet sur une demande déjà terminée
+		request.fail(fmt.Sprintf("pas de réponse après %v", timeout))
+		if request.Failure != "" {
+			missing = append(missing, request)
+			reasons = append(reasons, request.Failure)
+		}
+	}
+	if len(missing) > 0 {
+		reportMissingAcks(missing, reasons)
+	}
+	if n := helloRetransmissions(requests); n > 0 {
+		fmt.Printf("%d Hello retransmis faute de Hello Ack dans le délai de %v.\n", n, *helloRetry)
+	}
+	return missing
+}
+
+func waitHelloGoroutines(requests []*helloRequest, timeout time.Duration) {
+	/*
+		waitHelloGoroutines envoie les Hello dans des goroutines et attend, en temps réel, que les
+		demandes soient terminées ou que le délai soit dépassé.
+
+		Paramètres :
+			- requests : les demandes à envoyer
+			- timeout : la durée maximale d'attente
+
+		La fonction ne retourne rien.
+	*/
+	for _, request := range requests {
+		go hello(request)
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for _, request := range requests {
+		select {
+		case <-request.done:
+		case <-deadline.C:
+			// Le délai est dépassé : on relève sans attendre les demandes encore en cours
+			return
+		}
+	}
+}
+
+func waitHelloEvents(requests []*helloRequest, timeout time.Duration) {
+	/*
+		waitHelloEvents envoie les Hello en mode des et fait avancer la simulation jusqu'à ce que les
+		demandes soient terminées ou que le délai virtuel soit dépassé.
+
+		Paramètres :
+			- requests : les demandes à envoyer
+			- timeout : la durée maximale d'attente, sur l'horloge virtuelle
+
+		La fonction ne retourne rien.
+	*/
+	for _, request := range requests {
+		hello(request)
+	}
+	pending := 0 //les demandes avant cet indice sont terminées
+	engine.runUntil(engine.now+timeout, func() bool {
+		for pending < len(requests) && requests[pending].finished() {
+			pending++
+		}
+		return pending == len(requests)
+	})
+}
+
+func (request *helloRequest) finished() bool {
+	/*
+		finished indique si la demande est terminée (Hello Ack reçu ou échec).
+
+		Retourne :
+			- true si la demande est terminée
+	*/
+	select {
+	case <-request.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func reportMissingAcks(missing []*helloRequest, reasons []string) {
+	/*
+		reportMissingAcks affiche les paires source/destination qui n'ont pas reçu de Hello Ack.
+
+		Paramètres :
+			- missing : les demandes non acquittées
+			- reasons : la cause de l'échec de chaque demande
+
+		La fonction ne retourne rien.
+	*/
+	pairs := make([]string, len(missing))
+	for i, request := range missing {
+		pairs[i] = request.Source.Name + " -> " + request.Destination.Name + " (" + reasons[i] + ")"
+	}
+	fmt.Printf("\n%d Hello sans Hello Ack : %s\n", len(missing), strings.Join(pairs, ", "))
+}
+
+func (request *helloRequest) oneWay() time.Duration {
+	/*
+		oneWay retourne la latence de bout en bout du Hello : le temps entre son envoi par la source et
+		son arrivée à la destination, délais d'émission, de file et de propagation des liens compris.
+		Si le Hello a été retransmis, c'est la latence de l'exemplaire arrivé le premier.
+
+		Retourne :
+			- La latence du Hello, 0 si la demande n'a pas été acquittée
+	*/
+	if len(request.Path) == 0 {
+		return 0
+	}
+	return request.Path[len(request.Path)-1].At.Sub(request.Path[0].At)
+}
+
+func (request *helloRequest) roundTrip() time.Duration {
+	/*
+		roundTrip retourne le temps aller-retour de l'échange : de l'envoi du premier Hello à la
+		réception du Hello Ack, attente des retransmissions comprise.
+
+		Retourne :
+			- Le temps aller-retour, 0 si la demande n'a pas été acquittée
+	*/
+	if request.Acked.IsZero() {
+		return 0
+	}
+	return request.Acked.Sub(request.Sent)
+}
+
+func helloLatency(requests []*helloRequest) (int, time.Duration, time.Duration) {
+	/*
+		helloLatency calcule la latence de bout en bout moyenne et maximale des demandes acquittées.
+
+		Paramètres :
+			- requests : des demandes terminées
+
+		Retourne :
+			- Le nombre de demandes acquittées
+			- La latence moyenne et la latence maximale de leurs Hello
+	*/
+	count := 0
+	var total, worst time.Duration
+	for _, request := range requests {
+		if !request.finished() || request.Failure != "" {
+			continue
+		}
+		latency := request.oneWay()
+		count++
+		total += latency
+		worst = max(worst, latency)
+	}
+	if count == 0 {
+		return 0, 0, 0
+	}
+	return count, total / time.Duration(count), worst
+}
+
+func printHelloLatency(requests []*helloRequest) {
+	/*
+		printHelloLatency affiche la latence de bout en bout moyenne et maximale des Hello acquittés.
+
+		Paramètres :
+			- requests : des demandes terminées
+
+		La fonction ne retourne rien.
+	*/
+	count, average, worst := helloLatency(requests)
+	if count == 0 {
+		return
+	}
+	fmt.Printf("Latence de bout en bout des Hello : moyenne %v, maximum %v (%d Hello).\n",
+		average.Round(time.Microsecond), worst.Round(time.Microsecond), count)
+}
+
+func randomHelloRequests(g *Graph) []*helloRequest {
+	/*
+		randomHelloRequests crée une demande Hello depuis chaque nœud vers une destination aléatoire.
+		Les routeurs en panne n'envoient rien, mais peuvent être tirés comme destination.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Retourne :
+			- Une demande par nœud du graphe qui n'est pas en panne
+	*/
+	requests := make([]*helloRequest, 0, len(g.Nodes))
+	for _, nodeSrc := range g.Nodes {
+		if nodeSrc.Failed() {
+			continue
+		}
+		requests = append(requests, newHelloRequest(nodeSrc, randomDestination(g, nodeSrc)))
+	}
+	return requests
+}
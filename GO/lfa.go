@@ -0,0 +1,360 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+//**** PROTECTION DES ROUTES : LOOP-FREE ALTERNATES (LFA) ET LFA DISTANTS ****//
+
+// Next hop de secours d'un routeur vers une destination, pris dès que le lien vers le next hop principal tombe
+type backupRoute struct {
+	Primary *Node //next hop principal protégé
+	NextHop *Node //voisin vers lequel le message est renvoyé
+	Tunnel  *Node //routeur PQ d'un LFA distant, que le message rejoint avant sa destination ; nil pour un LFA
+	Cost    int   //coût du chemin de secours jusqu'à la destination
+}
+
+// Couverture des routes d'un routeur par les next hops de secours
+type lfaCoverage struct {
+	Router *Node
+	Routes int //destinations joignables
+	LFA    int //destinations protégées par un LFA
+	Remote int //destinations protégées par un LFA distant
+}
+
+// Compteurs globaux //
+var reroutedMessages atomic.Int64 //messages renvoyés par un next hop de secours
+var tunneledMessages atomic.Int64 //dont messages envoyés par un LFA distant
+
+func (node *Node) Backup(name string) *backupRoute {
+	/*
+		Backup retourne le next hop de secours du nœud pour une destination.
+
+		Paramètres :
+			- name : le nom de la destination
+
+		Retourne :
+			- Le secours, nil si la destination n'est pas protégée
+	*/
+	node.tableMu.RLock()
+	defer node.tableMu.RUnlock()
+	if backup, ok := node.backups[name]; ok {
+		return &backup
+	}
+	return nil
+}
+
+func (node *Node) setBackups(backups map[string]backupRoute) {
+	/*
+		setBackups remplace les next hops de secours du nœud.
+
+		Paramètres :
+			- backups : les secours par nom de destination, qui ne doivent plus être modifiés ensuite
+
+		La fonction ne retourne rien.
+	*/
+	node.tableMu.Lock()
+	node.backups = backups
+	node.tableMu.Unlock()
+}
+
+func routeCost(from *Node, to *Node) int {
+	/*
+		routeCost donne la distance d'un routeur à un autre d'après la table de routage du premier.
+
+		Paramètres :
+			- from : le routeur dont la table est lue
+			- to : la destination
+
+		Retourne :
+			- Le coût de la route, 0 si from est la destination, infiniteDistance si elle est injoignable
+	*/
+	if from == to {
+		return 0
+	}
+	entry := from.Route(to.Name)
+	if !entry.Reachable() {
+		return infiniteDistance
+	}
+	return entry.Cost
+}
+
+func findLFA(node *Node, dest *Node, primary *Node, edges []*Edge) (backupRoute, bool) {
+	/*
+		findLFA cherche un loop-free alternate (RFC 5286) : un voisin N de node, autre que le next hop
+		principal, dont le plus court chemin vers dest ne repasse pas par node, c'est-à-dire
+		dist(N, dest) < dist(N, node) + dist(node, dest). Un tel voisin ne renvoie jamais le message à
+		node et n'emprunte donc pas le lien tombé.
+
+		Paramètres :
+			- node : le routeur à protéger
+			- dest : la destination
+			- primary : le next hop principal de node vers dest
+			- edges : les liens de node
+
+		Parmi les voisins qui conviennent, celui dont le chemin de secours est le moins coûteux est
+		choisi (le premier dans l'ordre des liens en cas d'égalité).
+
+		Retourne :
+			- Le secours trouvé
+			- false s'il n'y a pas de LFA
+	*/
+	best := backupRoute{Cost: infiniteDistance}
+	distance := routeCost(node, dest)
+	for _, edge := range edges {
+		neighbor := edge.To
+		if neighbor == primary || neighbor.Failed() {
+			continue
+		}
+		toDest := routeCost(neighbor, dest)
+		if toDest == infiniteDistance || toDest >= routeCost(neighbor, node)+distance {
+			continue
+		}
+		if cost := edge.Weight + toDest; cost < best.Cost {
+			best = backupRoute{Primary: primary, NextHop: neighbor, Cost: cost}
+		}
+	}
+	return best, best.NextHop != nil
+}
+
+func findRemoteLFA(g *Graph, node *Node, dest *Node, primary *Node) (backupRoute, bool) {
+	/*
+		findRemoteLFA cherche un LFA distant (RFC 7490) quand aucun voisin ne convient : un routeur P,
+		rejoint par un tunnel, tel que
+			- le plus court chemin de node à P n'emprunte pas le lien node - primary (espace P) :
+			  dist(node, P) < poids(node, primary) + dist(primary, P) ;
+			- le plus court chemin de P à dest n'emprunte pas ce lien non plus (espace Q) :
+			  dist(P, dest) < dist(P, node) + poids(node, primary) + dist(primary, dest).
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- node : le routeur à protéger
+			- dest : la destination
+			- primary : le next hop principal de node vers dest
+
+		Le message est envoyé vers P par les routes normales, puis P le transmet à dest. Parmi les
+		routeurs qui conviennent, celui dont le chemin de secours est le moins coûteux est choisi. Le
+		calcul parcourt tous les routeurs pour chaque destination sans LFA.
+
+		Retourne :
+			- Le secours trouvé
+			- false s'il n'y a pas de LFA distant
+	*/
+	weight := linkWeight(node, primary)
+	primaryToDest := routeCost(primary, dest)
+	best := backupRoute{Cost: infiniteDistance}
+	for _, pq := range g.Nodes {
+		if pq == node || pq == primary || pq.Failed() {
+			continue
+		}
+		toPQ, pqToDest := routeCost(node, pq), routeCost(pq, dest)
+		if toPQ == infiniteDistance || pqToDest == infiniteDistance {
+			continue
+		}
+		if toPQ >= weight+routeCost(primary, pq) || pqToDest >= routeCost(pq, node)+weight+primaryToDest {
+			continue
+		}
+		if cost := toPQ + pqToDest; cost < best.Cost {
+			best = backupRoute{Primary: primary, NextHop: node.Route(pq.Name).NextHop, Tunnel: pq, Cost: cost}
+		}
+	}
+	return best, best.NextHop != nil
+}
+
+func computeBackups(g *Graph) {
+	/*
+		computeBackups précalcule, pour chaque routeur et chaque destination joignable, un next hop de
+		secours qui évite le lien vers le next hop principal : un LFA (voir findLFA), ou à défaut un LFA
+		distant (voir findRemoteLFA).
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Les distances sont lues dans les tables de routage des routeurs (celles que chacun connaît
+		après convergence : ses voisins avec le protocole à vecteur de distances, toute sa LSDB avec
+		le protocole à états de liens). La fonction est appelée après chaque convergence (voir
+		waitRoutingConvergence) ; rien n'est calculé avec -lfa=false.
+
+		La fonction ne retourne rien.
+	*/
+	if !*lfaFlag {
+		return
+	}
+	for _, node := range g.Nodes {
+		if node.Failed() {
+			node.setBackups(nil)
+			continue
+		}
+		node.edgesMu.RLock()
+		edges := append([]*Edge(nil), node.Edges...)
+		node.edgesMu.RUnlock()
+
+		table := node.Table()
+		backups := make(map[string]backupRoute)
+		for _, dest := range g.Nodes {
+			entry := table[dest.Name]
+			if dest == node || !entry.Reachable() || entry.NextHop == nil {
+				continue
+			}
+			if backup, ok := findLFA(node, dest, entry.NextHop, edges); ok {
+				backups[dest.Name] = backup
+			} else if backup, ok := findRemoteLFA(g, node, dest, entry.NextHop); ok {
+				backups[dest.Name] = backup
+			}
+		}
+		node.setBackups(backups)
+	}
+}
+
+func fastReroute(node *Node, target *Node, failed *Node, message Message) (*Node, Message) {
+	/*
+		fastReroute renvoie un message par le next hop de secours quand le lien de node vers le next hop
+		choisi n'existe plus (lien tombé ou voisin en panne, avant la reconvergence des tables).
+
+		Paramètres :
+			- node : le routeur qui transmet le message
+			- target : la destination de la route suivie (destination du message ou fin de son tunnel)
+			- failed : le next hop injoignable donné par la table
+			- message : le message à transmettre
+
+		Avec un LFA distant, le message est envoyé dans un tunnel vers le routeur PQ (champ Tunnel).
+		Sans secours utilisable, le message part vers failed comme avant, par le lien qui n'existe
+		plus (voir linkDelay).
+
+		Retourne :
+			- Le prochain saut du message
+			- Le message, avec son tunnel s'il passe par un LFA distant
+	*/
+	backup := node.Backup(target.Name)
+	if backup == nil || backup.Primary != failed || !edgeExists(node, backup.NextHop) {
+		return failed, message
+	}
+	reroutedMessages.Add(1)
+	if backup.Tunnel != nil {
+		tunneledMessages.Add(1)
+		message.Tunnel = backup.Tunnel
+	}
+	return backup.NextHop, message
+}
+
+func backupCoverage(g *Graph) []lfaCoverage {
+	/*
+		backupCoverage compte, pour chaque routeur en service, les destinations joignables protégées par
+		un LFA ou par un LFA distant.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Retourne :
+			- La couverture de chaque routeur, de la moins bonne à la meilleure
+	*/
+	var coverage []lfaCoverage
+	for _, node := range g.Nodes {
+		if node.Failed() {
+			continue
+		}
+		c := lfaCoverage{Router: node}
+		for name, entry := range node.Table() {
+			if entry.Destination == node || !entry.Reachable() {
+				continue
+			}
+			c.Routes++
+			switch backup := node.Backup(name); {
+			case backup == nil:
+			case backup.Tunnel == nil:
+				c.LFA++
+			default:
+				c.Remote++
+			}
+		}
+		coverage = append(coverage, c)
+	}
+	sort.SliceStable(coverage, func(i, j int) bool {
+		return coverage[i].share() < coverage[j].share()
+	})
+	return coverage
+}
+
+func (c lfaCoverage) share() float64 {
+	/*
+		share donne la part des destinations protégées d'un routeur.
+
+		Retourne :
+			- La part, entre 0 et 1 (1 si le routeur n'a aucune destination)
+	*/
+	if c.Routes == 0 {
+		return 1
+	}
+	return float64(c.LFA+c.Remote) / float64(c.Routes)
+}
+
+func printBackupCoverage(g *Graph, top int) {
+	/*
+		printBackupCoverage affiche la part des routes (paires routeur -> destination) protégées par un
+		LFA ou un LFA distant sur la topologie actuelle, et les routeurs les moins bien protégés.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- top : le nombre maximal de routeurs détaillés
+
+		La fonction ne retourne rien.
+	*/
+	if !*lfaFlag {
+		fmt.Println("Les next hops de secours ne sont pas calculés (-lfa=false).")
+		return
+	}
+	coverage := backupCoverage(g)
+	var total lfaCoverage
+	for _, c := range coverage {
+		total.Routes += c.Routes
+		total.LFA += c.LFA
+		total.Remote += c.Remote
+	}
+	if total.Routes == 0 {
+		fmt.Println("Aucune route à protéger.")
+		return
+	}
+	percent := func(n int, of int) float64 { return 100 * float64(n) / float64(of) }
+	unprotected := total.Routes - total.LFA - total.Remote
+	fmt.Printf("Protection des routes : %.1f %% des %d routes protégées (%.1f %% par un LFA, %.1f %% par un LFA distant), %d routes sans secours.\n",
+		100*total.share(), total.Routes, percent(total.LFA, total.Routes), percent(total.Remote, total.Routes), unprotected)
+	for i, c := range coverage {
+		if i == top || c.share() == 1 {
+			break
+		}
+		fmt.Printf("  %s : %.1f %% de %d destinations (%d LFA, %d LFA distants)\n", c.Router.Name, 100*c.share(), c.Routes, c.LFA, c.Remote)
+	}
+}
+
+func printBackups(node *Node) {
+	/*
+		printBackups affiche le next hop principal et le secours de chaque destination d'un routeur.
+
+		Paramètres :
+			- node : le routeur
+
+		La fonction ne retourne rien.
+	*/
+	table := node.Table()
+	names := make([]string, 0, len(table))
+	for name, entry := range table {
+		if entry.Destination != node && entry.Reachable() {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return table[names[i]].Destination.Index < table[names[j]].Destination.Index })
+	fmt.Printf("Secours de %s :\n", node.Name)
+	for _, name := range names {
+		entry := table[name]
+		switch backup := node.Backup(name); {
+		case backup == nil:
+			fmt.Printf("  %-6s %s, sans secours\n", name, describeRoute(entry))
+		case backup.Tunnel == nil:
+			fmt.Printf("  %-6s %s, LFA via %s (coût %d)\n", name, describeRoute(entry), backup.NextHop.Name, backup.Cost)
+		default:
+			fmt.Printf("  %-6s %s, LFA distant via %s jusqu'à %s (coût %d)\n", name, describeRoute(entry), backup.NextHop.Name, backup.Tunnel.Name, backup.Cost)
+		}
+	}
+}
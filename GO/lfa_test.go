@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func TestFindLFA(t *testing.T) {
+	/*
+		TestFindLFA vérifie les secours trouvés sur un anneau de 5 routeurs et sur un carré, tous les
+		liens de poids 1. Sur l'anneau impair, R1 protège R3 par un LFA et son voisin R2 par un LFA
+		distant ; sur le carré, la destination R3 a un LFA mais le voisin R2 n'a aucun secours : le
+		seul routeur de l'espace P (R4) est à égale distance de R2 par les deux côtés.
+	*/
+	ring := routersGraph(5, [][3]int{{0, 1, 1}, {1, 2, 1}, {2, 3, 1}, {3, 4, 1}, {4, 0, 1}})
+	square := routersGraph(4, [][3]int{{0, 1, 1}, {1, 2, 1}, {2, 3, 1}, {3, 0, 1}})
+	tests := []struct {
+		name    string
+		graph   Graph
+		dest    int
+		primary string
+		lfa     string //next hop du LFA, vide s'il n'y en a pas
+		remote  string //routeur PQ du LFA distant, vide s'il n'y en a pas
+		nextHop string //next hop du secours retenu
+		cost    int
+	}{
+		{"anneau, destination à deux sauts", ring, 2, "R2", "R5", "", "R5", 3},
+		{"anneau, voisin direct", ring, 1, "R2", "", "R4", "R5", 4},
+		{"carré, destination opposée", square, 2, "R2", "R4", "", "R4", 2},
+		{"carré, voisin direct", square, 1, "R2", "", "", "", 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			graph := test.graph
+			constructAllRoutingTables(&graph)
+			node, dest := graph.Nodes[0], graph.Nodes[test.dest]
+			primary := node.Route(dest.Name).NextHop
+			if primary.Name != test.primary {
+				t.Fatalf("next hop principal %s ; attendu %s", primary.Name, test.primary)
+			}
+
+			lfa, ok := findLFA(node, dest, primary, node.Edges)
+			if got := backupName(lfa.NextHop, ok); got != test.lfa {
+				t.Errorf("LFA %q ; attendu %q", got, test.lfa)
+			}
+			remote, remoteOK := findRemoteLFA(&graph, node, dest, primary)
+			if got := backupName(remote.Tunnel, remoteOK && !ok); got != test.remote {
+				t.Errorf("LFA distant %q ; attendu %q", got, test.remote)
+			}
+
+			backup := lfa
+			if !ok {
+				backup, ok = remote, remoteOK
+			}
+			if got := backupName(backup.NextHop, ok); got != test.nextHop {
+				t.Fatalf("secours par %q ; attendu %q", got, test.nextHop)
+			}
+			if ok && (backup.Cost != test.cost || backup.Primary != primary) {
+				t.Errorf("secours de coût %d protégeant %v ; attendu %d protégeant %s", backup.Cost, backup.Primary, test.cost, primary.Name)
+			}
+		})
+	}
+}
+
+func backupName(node *Node, ok bool) string {
+	/*
+		backupName donne le nom d'un routeur de secours pour comparer les résultats des tests.
+
+		Paramètres :
+			- node : le routeur, nil s'il n'y en a pas
+			- ok : false si aucun secours n'a été trouvé
+
+		Retourne :
+			- Le nom du routeur, vide s'il n'y a pas de secours
+	*/
+	if !ok || node == nil {
+		return ""
+	}
+	return node.Name
+}
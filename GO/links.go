@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+//**** MODÈLE DES LIENS : DÉLAI, DÉBIT ET FILE D'ÉMISSION ****//
+
+// Taille en octets de l'en-tête d'un message (source, destination, type, TTL)
+const messageHeaderSize = 24
+
+// Compteurs globaux //
+var droppedMessages atomic.Int64 //messages perdus parce que la file d'émission d'un lien était pleine
+
+func newEdge(to *Node, weight int) *Edge {
+	/*
+		newEdge crée un lien vers un nœud avec le délai, le débit, la taille de file et les taux
+		d'erreur donnés par les options -latency, -bandwidth, -queue, -loss, -corrupt et -duplicate.
+
+		Paramètres :
+			- to : le nœud à l'autre extrémité du lien
+			- weight : le poids du lien
+
+		Retourne :
+			- Le lien, dans un seul sens (chaque extrémité a le sien, avec sa propre file d'émission)
+	*/
+	return &Edge{To: to, Weight: weight, Delay: *latencyFlag, Bandwidth: *bandwidthFlag, QueueLimit: *queueFlag,
+		Loss: *lossFlag, Corruption: *corruptFlag, Duplication: *duplicateFlag}
+}
+
+func findEdge(nodeA *Node, nodeB *Node) *Edge {
+	/*
+		findEdge cherche le lien de nodeA vers nodeB.
+
+		Paramètres :
+			- nodeA : le nœud d'où part le lien
+			- nodeB : le nœud à l'autre extrémité
+
+		Retourne :
+			- Le lien, nil s'il n'existe pas
+	*/
+	nodeA.edgesMu.RLock()
+	defer nodeA.edgesMu.RUnlock()
+	for _, edge := range nodeA.Edges {
+		if edge.To == nodeB {
+			return edge
+		}
+	}
+	return nil
+}
+
+func removeEdge(nodeA *Node, nodeB *Node) *Edge {
+	/*
+		removeEdge supprime le lien de nodeA vers nodeB de la liste des liens de nodeA.
+
+		Paramètres :
+			- nodeA : le nœud d'où part le lien
+			- nodeB : le nœud à l'autre extrémité
+
+		Les routeurs qui transmettent des messages lisent les liens en même temps (voir findEdge) :
+		la liste n'est modifiée que sous le verrou edgesMu.
+
+		Retourne :
+			- Le lien supprimé, nil s'il n'existait pas
+	*/
+	nodeA.edgesMu.Lock()
+	defer nodeA.edgesMu.Unlock()
+	for i, edge := range nodeA.Edges {
+		if edge.To == nodeB {
+			// Eliminer le Edge de la liste de edges de A avec une technique de slicing
+			nodeA.Edges = append(nodeA.Edges[:i], nodeA.Edges[i+1:]...)
+			return edge
+		}
+	}
+	return nil
+}
+
+func addEdge(node *Node, edge *Edge) {
+	/*
+		addEdge ajoute un lien à la liste des liens d'un nœud, sous le verrou edgesMu.
+
+		Paramètres :
+			- node : le nœud d'où part le lien
+			- edge : le lien
+
+		La fonction ne retourne rien.
+	*/
+	node.edgesMu.Lock()
+	node.Edges = append(node.Edges, edge)
+	node.edgesMu.Unlock()
+}
+
+func messageSize(message Message) int {
+	/*
+		messageSize estime la taille d'un message sur un lien : l'en-tête, 8 octets par routeur de la
+		route enregistrée et la taille du contenu.
+
+		Paramètres :
+			- message : le message
+
+		Retourne :
+			- La taille du message en octets
+	*/
+	size := messageHeaderSize + 8*len(message.Route)
+	if message.Payload != nil {
+		size += message.Payload.size()
+	}
+	return size
+}
+
+func serializationDelay(size int, bandwidth int64) time.Duration {
+	/*
+		serializationDelay calcule le temps d'émission d'un message sur un lien.
+
+		Paramètres :
+			- size : la taille du message en octets
+			- bandwidth : le débit du lien en bits par seconde, 0 si illimité
+
+		Retourne :
+			- Le temps nécessaire pour émettre tous les bits du message
+	*/
+	if bandwidth <= 0 {
+		return 0
+	}
+	return time.Duration(int64(size) * 8 * int64(time.Second) / bandwidth)
+}
+
+func (edge *Edge) enqueue(size int) (time.Duration, bool) {
+	/*
+		enqueue place un message dans la file d'émission du lien.
+
+		Paramètres :
+			- size : la taille du message en octets
+
+		Les messages sont émis l'un après l'autre : un message attend la fin de l'émission des messages
+		déjà dans la file, puis met size*8/Bandwidth à être émis, puis Delay à se propager. Si la file
+		contient déjà QueueLimit messages (en attente ou en cours d'émission), le message est perdu
+		(tail drop). Un lien de débit illimité n'a pas de file : le message ne subit que Delay.
+
+		Retourne :
+			- Le délai avant la réception du message par l'autre extrémité
+			- false si le message est perdu
+	*/
+	edge.queueMu.Lock()
+	defer edge.queueMu.Unlock()
+	if edge.Bandwidth <= 0 {
+		edge.sent++
+		return edge.Delay, true
+	}
+	if edge.QueueLimit > 0 && edge.queued >= edge.QueueLimit {
+		edge.dropped++
+		droppedMessages.Add(1)
+		return 0, false
+	}
+
+	now := clockNow()
+	start := now
+	if edge.busyUntil.After(now) {
+		start = edge.busyUntil
+	}
+	edge.busyUntil = start.Add(serializationDelay(size, edge.Bandwidth))
+	edge.sent++
+	edge.queued++
+	if edge.queued > edge.maxQueued {
+		edge.maxQueued = edge.queued
+	}
+	departure := edge.busyUntil.Sub(now)
+	afterFunc(departure, func() {
+		edge.queueMu.Lock()
+		edge.queued--
+		edge.queueMu.Unlock()
+	})
+	return departure + edge.Delay, true
+}
+
+func linkDelay(from *Node, to *Node, message Message) (time.Duration, bool) {
+	/*
+		linkDelay calcule le délai de transmission d'un message sur le lien de from vers to.
+
+		Paramètres :
+			- from : le routeur qui envoie le message
+			- to : le voisin qui le reçoit
+			- message : le message, dont la taille détermine le temps d'émission
+
+		Un message envoyé par une route périmée vers un routeur qui n'est plus voisin (lien supprimé
+		pendant la convergence) est transmis avec le délai par défaut -latency, sans file d'émission.
+
+		Retourne :
+			- Le délai avant la réception du message
+			- false si le message est perdu (file d'émission pleine)
+	*/
+	edge := findEdge(from, to)
+	if edge == nil {
+		return *latencyFlag, true
+	}
+	return edge.enqueue(messageSize(message))
+}
+
+// Statistiques d'un sens d'un lien, relevées par linkStatistics
+type linkStats struct {
+	From, To  *Node
+	Sent      int64 //messages acceptés dans la file d'émission
+	Dropped   int64 //messages perdus, file pleine
+	MaxQueued int   //nombre maximal de messages dans la file
+}
+
+func linkStatistics(g *Graph) []linkStats {
+	/*
+		linkStatistics relève les compteurs de chaque sens de chaque lien.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Retourne :
+			- Les statistiques des liens, des plus grandes pertes aux plus petites, puis des files
+			  les plus chargées aux moins chargées
+	*/
+	var stats []linkStats
+	for _, node := range g.Nodes {
+		for _, edge := range node.Edges {
+			edge.queueMu.Lock()
+			stats = append(stats, linkStats{From: node, To: edge.To, Sent: edge.sent, Dropped: edge.dropped, MaxQueued: edge.maxQueued})
+			edge.queueMu.Unlock()
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Dropped != stats[j].Dropped {
+			return stats[i].Dropped > stats[j].Dropped
+		}
+		return stats[i].MaxQueued > stats[j].MaxQueued
+	})
+	return stats
+}
+
+func printLinkStatistics(g *Graph, top int) {
+	/*
+		printLinkStatistics affiche le nombre de messages émis et perdus sur les liens, et les liens
+		les plus chargés.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- top : le nombre maximal de liens détaillés
+
+		Rien n'est affiché si aucun lien n'a de débit limité, les files étant alors toujours vides.
+
+		La fonction ne retourne rien.
+	*/
+	stats := linkStatistics(g)
+	var sent, dropped int64
+	limited := false
+	for _, node := range g.Nodes {
+		for _, edge := range node.Edges {
+			limited = limited || edge.Bandwidth > 0
+		}
+	}
+	if !limited {
+		return
+	}
+	for _, s := range stats {
+		sent += s.Sent
+		dropped += s.Dropped
+	}
+	fmt.Printf("Liens : %d messages émis, %d perdus (file d'émission pleine).\n", sent, dropped)
+	for i, s := range stats {
+		if i == top || s.MaxQueued == 0 {
+			break
+		}
+		fmt.Printf("  %s -> %s : %d émis, %d perdus, jusqu'à %d messages en file\n", s.From.Name, s.To.Name, s.Sent, s.Dropped, s.MaxQueued)
+	}
+}
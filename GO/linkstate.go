@@ -0,0 +1,625 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+//**** PROTOCOLE À ÉTATS DE LIENS (TYPE OSPF) ****//
+
+// Lien décrit dans une annonce d'états de liens
+type lsaLink struct {
+	Neighbor *Node
+	Weight   int
+}
+
+// Annonce d'états de liens (LSA) : les liens d'un routeur, tels qu'il les connaît
+type LinkStateAdvertisement struct {
+	Origin   *Node         //routeur qui a créé l'annonce
+	Sequence int           //numéro de séquence, incrémenté à chaque nouvelle annonce de l'origine
+	Age      time.Duration //âge de l'annonce au moment de son envoi
+	Links    []lsaLink
+}
+
+// Annonce enregistrée dans la base d'états de liens (LSDB) d'un routeur
+type lsdbEntry struct {
+	LSA      *LinkStateAdvertisement
+	Received time.Time //date de réception, pour calculer l'âge actuel de l'annonce
+}
+
+// État du protocole à états de liens d'un nœud : ses voisins directs et sa LSDB
+type LinkState struct {
+	mu        sync.Mutex
+	lsdb      map[*Node]*lsdbEntry //dernière annonce reçue de chaque origine
+	neighbors map[*Node]int        //poids du lien vers chaque voisin
+	sequence  int                  //numéro de séquence de la dernière annonce du nœud
+}
+
+var lsProtocol = newRoutingProtocol("États de liens", "LSA") //suivi des annonces et de la convergence
+
+// Enregistrement du traitement des annonces du protocole
+func init() {
+	registerHandler(MessageLinkState, "link state", func(g *Graph, node *Node, message Message) {
+		handleLinkState(node, message)
+	})
+}
+
+func startLinkState(g *Graph) {
+	/*
+		startLinkState démarre le protocole à états de liens sur tous les nœuds du graphe.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Chaque nœud ne connaît au départ que le poids des liens vers ses voisins. Il crée une annonce
+		(LSA) décrivant ces liens et l'inonde vers ses voisins, qui la relaient à leur tour. Chaque nœud
+		construit ainsi sa propre base d'états de liens (LSDB) et calcule sa table de routage avec
+		Dijkstra sur cette base, sans lire les autres nœuds du graphe. Une goroutine par nœud recrée
+		l'annonce toutes les lsRefresh et supprime les annonces plus vieilles que lsMaxAge. Les
+		goroutines processMessages doivent être lancées pour que les annonces soient reçues.
+
+		La fonction ne retourne rien.
+	*/
+	lsProtocol.Delay = *lsFloodDelay
+	lsProtocol.markEvent()
+	for _, node := range g.Nodes {
+		ls := &LinkState{lsdb: make(map[*Node]*lsdbEntry), neighbors: make(map[*Node]int)}
+		for _, edge := range node.Edges {
+			ls.neighbors[edge.To] = edge.Weight
+		}
+		node.LS = ls
+	}
+	for _, node := range g.Nodes {
+		node := node
+		lsProtocol.every(*lsRefresh/4, func(now time.Time) { linkStateTick(node, now) })
+		originateLinkState(node)
+	}
+}
+
+func stopLinkState() {
+	/*
+		stopLinkState arrête les annonces périodiques et attend la fin des envois en cours, pour que
+		les canaux puissent ensuite être fermés sans qu'une annonce y soit encore envoyée.
+
+		La fonction ne retourne rien.
+	*/
+	lsProtocol.shutdown()
+}
+
+func linkStateTick(node *Node, now time.Time) {
+	/*
+		linkStateTick fait vieillir la LSDB du nœud : les annonces qui ont atteint lsMaxAge sont
+		supprimées (leur origine a disparu ou n'est plus joignable) et l'annonce du nœud est recréée
+		quand elle a plus de lsRefresh, pour que les autres routeurs ne la suppriment pas.
+
+		Paramètres :
+			- node : le nœud dont on fait vieillir la base
+			- now : la date du tick
+
+		La base d'un routeur en panne ne vieillit pas : elle a été effacée par resetLinkState.
+
+		La fonction ne retourne rien.
+	*/
+	if node.Failed() {
+		return
+	}
+	ls := node.LS
+	ls.mu.Lock()
+	refresh := false
+	purged := false
+	for origin, entry := range ls.lsdb {
+		age := entry.age(now)
+		if origin == node {
+			refresh = age >= *lsRefresh
+		} else if age >= *lsMaxAge {
+			logLinkState(node, "supprime la LSA de %s (seq %d, âge maximal atteint)", origin.Name, entry.LSA.Sequence)
+			delete(ls.lsdb, origin)
+			purged = true
+		}
+	}
+	if purged {
+		computeLinkStateRoutes(node)
+	}
+	ls.mu.Unlock()
+	if refresh {
+		originateLinkState(node)
+	}
+}
+
+func (entry *lsdbEntry) age(now time.Time) time.Duration {
+	/*
+		age retourne l'âge actuel d'une annonce : son âge à l'envoi plus le temps écoulé depuis sa réception.
+
+		Paramètres :
+			- now : la date à laquelle on calcule l'âge
+
+		Retourne :
+			- L'âge de l'annonce
+	*/
+	return entry.LSA.Age + now.Sub(entry.Received)
+}
+
+func originateLinkState(node *Node) {
+	/*
+		originateLinkState crée une nouvelle annonce décrivant les liens actuels du nœud, avec un
+		numéro de séquence incrémenté, l'installe dans sa LSDB et l'inonde vers tous ses voisins.
+
+		Paramètres :
+			- node : le nœud qui crée l'annonce
+
+		La fonction ne retourne rien.
+	*/
+	ls := node.LS
+	ls.mu.Lock()
+	ls.sequence++
+	lsa := &LinkStateAdvertisement{Origin: node, Sequence: ls.sequence, Links: make([]lsaLink, 0, len(ls.neighbors))}
+	for neighbor, weight := range ls.neighbors {
+		lsa.Links = append(lsa.Links, lsaLink{Neighbor: neighbor, Weight: weight})
+	}
+	sort.Slice(lsa.Links, func(i, j int) bool { return lsa.Links[i].Neighbor.Name < lsa.Links[j].Neighbor.Name })
+	ls.lsdb[node] = &lsdbEntry{LSA: lsa, Received: clockNow()}
+	computeLinkStateRoutes(node)
+	neighbors := linkStateNeighbors(ls, nil)
+	ls.mu.Unlock()
+
+	for _, neighbor := range neighbors {
+		sendLinkState(node, neighbor, lsa, 0)
+	}
+}
+
+func sendLinkState(node *Node, neighbor *Node, lsa *LinkStateAdvertisement, age time.Duration) {
+	/*
+		sendLinkState envoie une annonce à un voisin, avec l'âge qu'elle a atteint dans la LSDB du nœud.
+
+		Paramètres :
+			- node : le nœud qui envoie l'annonce
+			- neighbor : le voisin destinataire
+			- lsa : l'annonce, qui n'est jamais modifiée après sa création
+			- age : l'âge actuel de l'annonce
+
+		La fonction ne retourne rien.
+	*/
+	copied := *lsa
+	copied.Age = age
+	lsProtocol.send(neighbor, Message{Source: node, Destination: neighbor, Type: MessageLinkState, Payload: &copied})
+}
+
+func handleLinkState(node *Node, received Message) {
+	/*
+		handleLinkState traite une annonce d'états de liens reçue d'un voisin (inondation fiable).
+
+		Paramètres :
+			- node : le nœud qui a reçu l'annonce
+			- received : le message contenant l'annonce
+
+		Si l'annonce est plus récente que celle de la LSDB (numéro de séquence plus grand), elle est
+		installée, relayée à tous les voisins sauf celui qui l'a envoyée, et la table de routage est
+		recalculée. Si elle est plus ancienne, notre copie est renvoyée au voisin pour qu'il se mette
+		à jour. Une annonce identique est ignorée, ce qui arrête l'inondation. Si un voisin relaie
+		une ancienne annonce du nœud lui-même avec un numéro plus grand que le sien, le nœud reprend
+		la numérotation au-dessus et crée une nouvelle annonce.
+
+		La fonction ne retourne rien.
+	*/
+	ls := node.LS
+	lsa := received.Payload.(*LinkStateAdvertisement)
+	neighbor := received.Source
+	ls.mu.Lock()
+	if _, ok := ls.neighbors[neighbor]; !ok {
+		// Le lien avec ce voisin n'existe plus : l'annonce est ignorée
+		ls.mu.Unlock()
+		return
+	}
+	now := clockNow()
+	if lsa.Origin == node {
+		if lsa.Sequence > ls.sequence {
+			ls.sequence = lsa.Sequence
+			ls.mu.Unlock()
+			originateLinkState(node)
+			return
+		}
+		ls.mu.Unlock()
+		return
+	}
+	current, known := ls.lsdb[lsa.Origin]
+	switch {
+	case !known || lsa.Sequence > current.LSA.Sequence:
+		if lsa.Age >= *lsMaxAge {
+			ls.mu.Unlock()
+			return
+		}
+		ls.lsdb[lsa.Origin] = &lsdbEntry{LSA: lsa, Received: now}
+		logLinkState(node, "installe la LSA de %s (seq %d) reçue de %s", lsa.Origin.Name, lsa.Sequence, neighbor.Name)
+		computeLinkStateRoutes(node)
+		neighbors := linkStateNeighbors(ls, neighbor)
+		ls.mu.Unlock()
+		for _, next := range neighbors {
+			sendLinkState(node, next, lsa, lsa.Age)
+		}
+	case lsa.Sequence < current.LSA.Sequence:
+		ours := current.LSA
+		age := current.age(now)
+		ls.mu.Unlock()
+		sendLinkState(node, neighbor, ours, age)
+	default:
+		ls.mu.Unlock()
+	}
+}
+
+func linkStateLinkDown(node *Node, neighbor *Node) {
+	/*
+		linkStateLinkDown signale au nœud que le lien vers un voisin a disparu : le nœud crée une
+		nouvelle annonce sans ce lien et l'inonde vers ses voisins restants.
+
+		Paramètres :
+			- node : le nœud qui a perdu le lien
+			- neighbor : le voisin qui n'est plus joignable directement
+
+		La fonction ne retourne rien.
+	*/
+	lsProtocol.markEvent()
+	ls := node.LS
+	ls.mu.Lock()
+	delete(ls.neighbors, neighbor)
+	ls.mu.Unlock()
+	originateLinkState(node)
+}
+
+func linkStateLinkUp(node *Node, neighbor *Node, weight int) {
+	/*
+		linkStateLinkUp signale au nœud qu'un lien vers un nouveau voisin est disponible. Le nœud
+		crée une nouvelle annonce avec ce lien, puis envoie toute sa LSDB au nouveau voisin
+		(synchronisation des bases, comme l'échange de descriptions de bases d'OSPF).
+
+		Paramètres :
+			- node : le nœud qui a obtenu le lien
+			- neighbor : le nouveau voisin
+			- weight : le poids du lien
+
+		La fonction ne retourne rien.
+	*/
+	lsProtocol.markEvent()
+	ls := node.LS
+	ls.mu.Lock()
+	ls.neighbors[neighbor] = weight
+	ls.mu.Unlock()
+	originateLinkState(node)
+
+	now := clockNow()
+	ls.mu.Lock()
+	origins := make([]*Node, 0, len(ls.lsdb))
+	for origin := range ls.lsdb {
+		if origin != node {
+			origins = append(origins, origin)
+		}
+	}
+	sortNodes(origins)
+	entries := make([]*lsdbEntry, len(origins))
+	ages := make([]time.Duration, len(origins))
+	for i, origin := range origins {
+		entries[i] = ls.lsdb[origin]
+		ages[i] = entries[i].age(now)
+	}
+	ls.mu.Unlock()
+	for i, entry := range entries {
+		sendLinkState(node, neighbor, entry.LSA, ages[i])
+	}
+}
+
+func linkStateLinkCost(node *Node, neighbor *Node, weight int) {
+	/*
+		linkStateLinkCost signale au nœud que le poids du lien vers un voisin a changé : le nœud crée une
+		nouvelle annonce avec ce poids et l'inonde vers ses voisins.
+
+		Paramètres :
+			- node : le nœud dont le lien a changé de poids
+			- neighbor : le voisin à l'autre extrémité du lien
+			- weight : le nouveau poids
+
+		La fonction ne retourne rien.
+	*/
+	lsProtocol.markEvent()
+	ls := node.LS
+	ls.mu.Lock()
+	if _, ok := ls.neighbors[neighbor]; !ok {
+		ls.mu.Unlock()
+		return
+	}
+	ls.neighbors[neighbor] = weight
+	ls.mu.Unlock()
+	originateLinkState(node)
+}
+
+func resetLinkState(node *Node) {
+	/*
+		resetLinkState efface la LSDB d'un routeur tombé en panne. Il ne garde que son numéro de
+		séquence, pour que ses annonces après son redémarrage remplacent les anciennes chez les autres
+		routeurs, et recevra les annonces de ses voisins à son redémarrage (voir linkStateLinkUp).
+
+		Paramètres :
+			- node : le routeur en panne
+
+		La fonction ne retourne rien.
+	*/
+	ls := node.LS
+	ls.mu.Lock()
+	ls.lsdb = make(map[*Node]*lsdbEntry)
+	ls.neighbors = make(map[*Node]int)
+	ls.mu.Unlock()
+	originateLinkState(node)
+}
+
+func linkStateNeighbors(ls *LinkState, except *Node) []*Node {
+	/*
+		linkStateNeighbors retourne les voisins vers lesquels inonder une annonce.
+		Le verrou ls.mu doit être tenu par l'appelant.
+
+		Paramètres :
+			- ls : l'état du protocole du nœud
+			- except : le voisin dont l'annonce a été reçue (il ne la reçoit pas en retour), ou nil
+
+		Retourne :
+			- La liste des voisins
+	*/
+	neighbors := make([]*Node, 0, len(ls.neighbors))
+	for neighbor := range ls.neighbors {
+		if neighbor != except {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+	return sortNodes(neighbors)
+}
+
+func computeLinkStateRoutes(node *Node) {
+	/*
+		computeLinkStateRoutes calcule la table de routage du nœud avec Dijkstra sur sa propre LSDB.
+		Le verrou node.LS.mu doit être tenu par l'appelant.
+
+		Paramètres :
+			- node : le nœud dont on calcule la table
+
+		Un lien n'est utilisé que s'il est annoncé par ses deux extrémités (vérification
+		bidirectionnelle d'OSPF), ce qui ignore les liens supprimés tant que l'une des deux annonces
+		n'est pas arrivée. Les destinations absentes de la LSDB n'apparaissent pas dans la table, et
+		celles de la LSDB qui ne sont plus joignables y sont publiées comme injoignables. Tant que la
+		LSDB ne contient pas l'annonce du nœud lui-même (annonce d'un voisin reçue avant
+		originateLinkState), la table n'est pas calculée.
+
+		La fonction ne retourne rien.
+	*/
+	ls := node.LS
+	nodes := make([]*Node, 0, len(ls.lsdb))
+	index := make(map[*Node]int, len(ls.lsdb))
+	for origin := range ls.lsdb {
+		nodes = append(nodes, origin)
+	}
+	// Numérotation dans l'ordre du graphe, pour que les égalités de coût soient départagées de la même façon à chaque exécution
+	for i, origin := range sortNodes(nodes) {
+		index[origin] = i
+	}
+	start, ok := index[node]
+	if !ok {
+		return
+	}
+	adj := make([][]arc, len(nodes))
+	for i, origin := range nodes {
+		for _, link := range ls.lsdb[origin].LSA.Links {
+			j, ok := index[link.Neighbor]
+			if ok && linkStateAdvertises(ls.lsdb[link.Neighbor].LSA, origin) {
+				adj[i] = append(adj[i], arc{To: j, Weight: link.Weight})
+			}
+		}
+	}
+
+	scratch := scratchPool.Get().(*dijkstraScratch)
+	shortestPaths(adj, start, scratch)
+	previous := node.Table()
+	table := buildRoutingTable(node, nodes, scratch)
+	scratchPool.Put(scratch)
+
+	for name, entry := range table {
+		old, ok := previous[name]
+		if !ok || old.NextHop != entry.NextHop || old.Cost != entry.Cost {
+			lsProtocol.routeChanged()
+		}
+	}
+	for name := range previous {
+		if _, ok := table[name]; !ok {
+			lsProtocol.routeChanged()
+		}
+	}
+	node.setRoutingTable(table)
+}
+
+func linkStateAdvertises(lsa *LinkStateAdvertisement, neighbor *Node) bool {
+	/*
+		linkStateAdvertises indique si une annonce décrit un lien vers neighbor.
+
+		Paramètres :
+			- lsa : l'annonce
+			- neighbor : le voisin recherché
+
+		Retourne :
+			- true si le lien est annoncé, false sinon
+	*/
+	for _, link := range lsa.Links {
+		if link.Neighbor == neighbor {
+			return true
+		}
+	}
+	return false
+}
+
+func linkStateSequences(node *Node) map[*Node]int {
+	/*
+		linkStateSequences retourne une copie des numéros de séquence de la LSDB du nœud.
+
+		Paramètres :
+			- node : le nœud dont on lit la base
+
+		Retourne :
+			- Le numéro de séquence de l'annonce de chaque origine présente dans la LSDB
+	*/
+	ls := node.LS
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	sequences := make(map[*Node]int, len(ls.lsdb))
+	for origin, entry := range ls.lsdb {
+		sequences[origin] = entry.LSA.Sequence
+	}
+	return sequences
+}
+
+func lsdbInconsistencies(g *Graph) map[*Node][]*Node {
+	/*
+		lsdbInconsistencies compare la LSDB de chaque routeur aux annonces actuelles de leurs origines.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		La référence est l'annonce que chaque routeur a créée en dernier (celle de sa propre LSDB).
+		Une LSDB est incohérente si une annonce y manque ou n'a pas le dernier numéro de séquence, ou
+		si elle contient une annonce d'un routeur qui ne s'annonce plus. Pendant la convergence, des
+		routeurs calculent donc leurs routes sur des vues différentes du réseau. Les routeurs en panne
+		ne sont pas comparés : leur LSDB est vide, et leur dernière annonce reste chez les autres
+		routeurs jusqu'à atteindre lsMaxAge.
+
+		Retourne :
+			- Pour chaque routeur dont la LSDB est incohérente, les origines manquantes ou périmées
+	*/
+	sequences := make(map[*Node]map[*Node]int, len(g.Nodes))
+	for _, node := range g.Nodes {
+		sequences[node] = linkStateSequences(node)
+	}
+	stale := make(map[*Node][]*Node)
+	for _, node := range g.Nodes {
+		for _, origin := range g.Nodes {
+			if node.Failed() || origin.Failed() {
+				continue
+			}
+			reference, announced := sequences[origin][origin]
+			seq, known := sequences[node][origin]
+			if announced != known || seq != reference {
+				stale[node] = append(stale[node], origin)
+			}
+		}
+	}
+	return stale
+}
+
+func waitLinkStateConvergence(g *Graph, timeout time.Duration) bool {
+	/*
+		waitLinkStateConvergence attend la fin de l'inondation et affiche, en plus du temps de
+		convergence, le nombre maximal de routeurs dont la LSDB était incohérente pendant l'attente.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- timeout : la durée maximale d'attente
+
+		Les LSDB sont comparées toutes les millisecondes (virtuelles en mode des) pendant la convergence. Avec l'option
+		-ls-flood-delay, l'inondation est assez lente pour observer les routeurs qui calculent leurs
+		routes sur une vue périmée du réseau.
+
+		Retourne :
+			- true si le protocole a convergé, false si le délai a été dépassé
+	*/
+	quiet := 100*time.Millisecond + 2**lsFloodDelay
+	var converged bool
+	worst := 0
+	if engine != nil {
+		// En mode des, la comparaison est un événement programmé chaque milliseconde virtuelle
+		sampling := true
+		var sample func()
+		sample = func() {
+			if !sampling {
+				return
+			}
+			worst = max(worst, len(lsdbInconsistencies(g)))
+			engine.schedule(time.Millisecond, sample)
+		}
+		sample()
+		converged = lsProtocol.waitConvergence(quiet, timeout)
+		sampling = false
+	} else {
+		done := make(chan struct{})
+		result := make(chan int)
+		go func() {
+			worst := 0
+			for {
+				if n := len(lsdbInconsistencies(g)); n > worst {
+					worst = n
+				}
+				select {
+				case <-done:
+					result <- worst
+					return
+				case <-time.After(time.Millisecond):
+				}
+			}
+		}()
+		converged = lsProtocol.waitConvergence(quiet, timeout)
+		close(done)
+		worst = <-result
+	}
+	remaining := len(lsdbInconsistencies(g))
+	fmt.Printf("LSDB incohérentes pendant la convergence : jusqu'à %d routeurs sur %d (%d après convergence).\n\n", worst, len(g.Nodes), remaining)
+	return converged
+}
+
+func printLinkStateDatabases(g *Graph, node *Node) {
+	/*
+		printLinkStateDatabases affiche les routeurs dont la LSDB est incohérente, puis la LSDB d'un routeur.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- node : le routeur dont on affiche la LSDB
+
+		La fonction ne retourne rien.
+	*/
+	stale := lsdbInconsistencies(g)
+	if len(stale) == 0 {
+		fmt.Printf("Les LSDB des %d routeurs sont identiques.\n", len(g.Nodes))
+	} else {
+		fmt.Printf("%d routeurs sur %d ont une LSDB incohérente :\n", len(stale), len(g.Nodes))
+		for _, other := range g.Nodes {
+			if origins, ok := stale[other]; ok {
+				fmt.Printf("  %s : annonces manquantes ou périmées de %s\n", other.Name, formatPath(origins))
+			}
+		}
+	}
+
+	ls := node.LS
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	now := clockNow()
+	fmt.Printf("\nLSDB de %s (%d annonces) :\n", node.Name, len(ls.lsdb))
+	for _, origin := range g.Nodes {
+		entry, ok := ls.lsdb[origin]
+		if !ok {
+			continue
+		}
+		fmt.Printf("  %-6s seq %-4d âge %-8v liens :", origin.Name, entry.LSA.Sequence, entry.age(now).Round(time.Millisecond))
+		for _, link := range entry.LSA.Links {
+			fmt.Printf(" %s(%d)", link.Neighbor.Name, link.Weight)
+		}
+		fmt.Println()
+	}
+}
+
+func logLinkState(node *Node, format string, args ...interface{}) {
+	/*
+		logLinkState affiche un événement du protocole à états de liens si l'option -ls-verbose est utilisée.
+
+		Paramètres :
+			- node : le nœud concerné
+			- format, args : la description de l'événement, au format de fmt.Printf
+
+		La fonction ne retourne rien.
+	*/
+	if !*lsVerbose {
+		return
+	}
+	fmt.Printf("[LS] %s %s\n", node.Name, fmt.Sprintf(format, args...))
+}
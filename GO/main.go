@@ -1,10 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"math/rand"
+	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -12,35 +16,84 @@ import (
 
 // Structure définissant un graphe
 type Graph struct {
-	Nodes []*Node
+	Nodes   []*Node
+	stopped bool //canaux fermés par stopNetwork
 }
 
 // Structure définissant un nœud dans le graphe
 type Node struct {
 	Name         string
 	Edges        []*Edge
+	edgesMu      sync.RWMutex //protège Edges et le poids des liens pendant les changements de liens (voir findEdge, addEdge, removeEdge)
 	Channel      chan Message
-	RoutingTable map[string]map[string]*Node //Table de routage de chaque node qui contient tous les autres sommets avec le next_hop (sans distance)
+	Index        int                      //position du nœud dans Graph.Nodes, mise à jour par startNetwork et buildAdjacency
+	RoutingTable map[string]*RoutingEntry //Table de routage de chaque node qui contient tous les autres sommets (indexés par leur nom)
+	tableMu      sync.RWMutex             //protège RoutingTable, remplacée d'un bloc par Dijkstra ou par les protocoles
+	DV           *DistanceVector          //état du protocole à vecteur de distances (option -protocol dv)
+	LS           *LinkState               //état du protocole à états de liens (option -protocol ls)
+	failed       atomic.Bool              //routeur en panne (voir failRouter)
+	quit         chan struct{}            //fermé pour arrêter la goroutine qui lit Channel (processMessages ou drainMessages)
+	savedLinks   []savedLink              //liens perdus pendant la panne, rétablis par recoverRouter
+	backups      map[string]backupRoute   //next hops de secours par destination (voir computeBackups), protégés par tableMu
+}
+
+// Structure définissant une entrée de la table de routage d'un nœud
+type RoutingEntry struct {
+	Source      *Node
+	Destination *Node
+	NextHop     *Node         //nil si la destination est injoignable
+	NextHops    []*Node       //tous les next hops des chemins de même coût, NextHop en premier, nil s'il n'y en a qu'un (voir ecmp.go)
+	Cost        int           //somme des poids du chemin, infiniteDistance si la destination est injoignable
+	HopCount    int           //nombre de liens du chemin
+	Parent      *RoutingEntry //entrée du nœud précédent la destination sur le chemin, nil pour la source
+	Updated     time.Time     //dernière fois que le next hop ou le coût de l'entrée a changé
 }
 
 // Structure définissant une arête reliant deux nœuds
 type Edge struct {
-	To     *Node
-	Weight int
+	To          *Node
+	Weight      int
+	Delay       time.Duration //délai de propagation sur le lien
+	Bandwidth   int64         //débit en bits par seconde, 0 si illimité (pas de temps d'émission ni de file)
+	QueueLimit  int           //nombre maximal de messages dans la file d'émission, 0 si illimité
+	Loss        float64       //probabilité qu'un message soit perdu sur le lien
+	Corruption  float64       //probabilité qu'un message soit altéré sur le lien
+	Duplication float64       //probabilité qu'un message soit remis deux fois
+
+	queueMu   sync.Mutex //protège la file d'émission et les compteurs ci-dessous (voir enqueue)
+	busyUntil time.Time  //date de fin d'émission du dernier message accepté
+	queued    int        //messages en attente ou en cours d'émission
+	maxQueued int
+	sent      int64
+	dropped   int64
 }
 
 // Structure définissant un message envoyé entre nœuds
 type Message struct {
 	Source      *Node
 	Destination *Node
-	Content     string
-	Route       map[*Node]struct{}
-	LinkDetails LinkInfo //info contenue dans les messages pour informer qu'on a perdu ou établi un nouveau lien
+	Type        MessageType //détermine la fonction de traitement (voir registerHandler)
+	TTL         int         //nombre de sauts restants, décrémenté par chaque routeur qui relaie le message
+	Route       []Hop       //nœuds traversés par le message, dans l'ordre
+	Loop        []*Node     //première boucle de routage détectée sur la route, nil sinon
+	Payload     Payload     //contenu propre au type : demande Hello, lien modifié, vecteur de distances ou LSA
+	Flow        uint64      //identifiant du flux, qui choisit le chemin parmi les chemins de même coût (voir nextHopFor)
+	Tunnel      *Node       //routeur PQ d'un LFA distant que le message rejoint avant sa destination, nil sinon (voir fastReroute)
+	Corrupted   bool        //altéré sur un lien : la somme de contrôle est fausse et le message sera détruit à sa réception
+}
+
+// Structure définissant un passage d'un message par un nœud
+type Hop struct {
+	Node   *Node
+	At     time.Time //date d'arrivée du message sur le nœud
+	Weight int       //poids du lien emprunté pour arriver sur le nœud, 0 pour la source
 }
 
+// Structure définissant le lien perdu, établi ou modifié, contenu des messages MessageLinkDown, MessageLinkUp et MessageLinkCost
 type LinkInfo struct {
-	NodeA *Node
-	NodeB *Node //nodes qui ont perdu ou récuperé un lien
+	NodeA  *Node
+	NodeB  *Node //nodes qui ont perdu ou récuperé un lien
+	Weight int   //poids du lien ajouté (1 si 0) ou nouveau poids du lien, ignoré pour une suppression
 }
 
 //**** INITIALISATION ****//
@@ -55,81 +108,164 @@ const (
 var numWorkers = runtime.NumCPU()
 var waitGroup sync.WaitGroup
 var dijWaitGroup sync.WaitGroup
-var helloWG sync.WaitGroup
 var closeWaitGroup sync.WaitGroup
-var ackReceived = 0
 var nodesCount int
 var maxEdges int
+var rng *rand.Rand       //générateur aléatoire de la simulation (graphe et trafic), initialisé avec la graine
+var simulationSeed int64 //graine de rng, donnée par newSeed dans buildGraph
+
+// Protocoles de construction des tables de routage (option -protocol)
+const (
+	protocolDijkstra       = "dijkstra" //calcul centralisé sur tout le graphe
+	protocolDistanceVector = "dv"       //vecteurs de distances échangés entre voisins (type RIP)
+	protocolLinkState      = "ls"       //annonces d'états de liens inondées dans tout le réseau (type OSPF)
+)
+
+// Options de la ligne de commande //
+var protocolFlag = flag.String("protocol", protocolDijkstra, "construction des tables de routage : dijkstra (calcul centralisé), dv (vecteurs de distances) ou ls (états de liens)")
+var dvPeriod = flag.Duration("dv-period", time.Second, "période des mises à jour du protocole à vecteur de distances")
+var dvInfinity = flag.Int("dv-infinity", 1000, "coût considéré comme infini par le protocole à vecteur de distances")
+var dvSplitHorizon = flag.Bool("dv-split-horizon", true, "ne pas annoncer une route au voisin par lequel elle a été apprise")
+var dvPoisonReverse = flag.Bool("dv-poison-reverse", true, "annoncer avec un coût infini une route au voisin par lequel elle a été apprise")
+var dvVerbose = flag.Bool("dv-verbose", false, "afficher chaque changement de route du protocole à vecteur de distances")
+var lsRefresh = flag.Duration("ls-refresh", 10*time.Second, "période de renouvellement des annonces du protocole à états de liens")
+var lsMaxAge = flag.Duration("ls-max-age", 30*time.Second, "âge au bout duquel une annonce d'états de liens non renouvelée est supprimée")
+var lsFloodDelay = flag.Duration("ls-flood-delay", 0, "délai de transmission de chaque annonce d'états de liens vers un voisin")
+var lsVerbose = flag.Bool("ls-verbose", false, "afficher l'installation et la suppression des annonces d'états de liens")
+var helloTimeout = flag.Duration("hello-timeout", 10*time.Second, "délai d'attente des Hello Ack, après lequel les paires sans réponse sont affichées")
+var ttlFlag = flag.Int("ttl", 64, "nombre maximal de routeurs qu'un message peut traverser avant d'être détruit")
+var nodesFlag = flag.Int("n", 0, "nombre de routeurs du graphe aléatoire (minimum 10)")
+var interfacesFlag = flag.Int("i", 0, "nombre maximal d'interfaces de chaque routeur (au moins le plus grand degré pour les autres formes)")
+var topologyPath = flag.String("topology", "", "fichier JSON ou YAML décrivant la topologie (remplace le graphe aléatoire)")
+var dotDir = flag.String("dot", "", "dossier où exporter la topologie au format DOT après chaque ajout ou suppression de lien")
+var diffDir = flag.String("diff", "", "dossier où exporter en JSON les routes modifiées par chaque changement de topologie")
+var seedFlag = flag.Int64("seed", 0, "graine aléatoire pour rejouer une exécution (0 = graine basée sur l'heure)")
+var modeFlag = flag.String("mode", modeGoroutines, "exécution de la simulation : goroutines (une goroutine par routeur, temps réel) ou des (événements discrets, horloge virtuelle)")
+var latencyFlag = flag.Duration("latency", time.Millisecond, "délai de propagation des liens (sauf ceux dont le fichier de topologie donne le délai)")
+var bandwidthFlag = flag.Int64("bandwidth", 0, "débit des liens en bits par seconde, 0 = illimité (sauf ceux dont le fichier de topologie donne le débit)")
+var queueFlag = flag.Int("queue", 0, "nombre maximal de messages dans la file d'émission d'un lien, 0 = illimité (sauf ceux dont le fichier de topologie donne la taille)")
+var lossFlag = flag.Float64("loss", 0, "probabilité qu'un message Hello, Hello Ack ou notification soit perdu sur un lien (sauf liens dont le fichier de topologie donne le taux)")
+var corruptFlag = flag.Float64("corrupt", 0, "probabilité qu'un message soit altéré sur un lien, puis détruit par le routeur qui le reçoit")
+var duplicateFlag = flag.Float64("duplicate", 0, "probabilité qu'un message soit remis deux fois par un lien")
+var helloRetry = flag.Duration("hello-retry", time.Second, "délai après lequel un Hello sans Hello Ack est renvoyé (0 = pas de retransmission)")
+var helloRetries = flag.Int("hello-retries", 3, "nombre maximal de retransmissions d'un Hello")
+var shapeFlag = flag.String("shape", shapeRandom, "forme du graphe généré : random, ring, grid, star, tree, fat-tree[:k], waxman[:alpha:beta] ou ba[:m]")
+var weightsFlag = flag.String("weights", defaultWeights.String(), "loi des poids des liens générés : constant:w, uniform:min:max, normal:moyenne:écart-type ou exponential:moyenne")
+var ecmpFlag = flag.Bool("ecmp", true, "répartir les flux entre les chemins de même coût (sinon toujours le premier next hop)")
+var lfaFlag = flag.Bool("lfa", true, "précalculer des next hops de secours (LFA, LFA distants) pris dès qu'un lien tombe, avant la reconvergence")
+var dotRoot = flag.String("dot-root", "", "routeur dont l'arbre des plus courts chemins est mis en évidence dans les exports DOT")
 
 // **** CRÉATION GRAPHE ALÉATOIRE ****//
 
-func initRandomGraph(nodesCount int, maxEdgesPerNode int) Graph {
+func initRandomGraph(nodesCount int, maxEdgesPerNode int, weights weightDistribution, r *rand.Rand) (Graph, error) {
 	/*
 		initRandomGraph initialise et retourne un graphe aléatoire caractérisé par les paramètres de la fonction.
 
 		Paramètres :
 			- nodesCount : le nombre de nœuds dans le graphe
 			- maxEdgesPerNode : le nombre maximal d'arêtes par nœud
+			- weights : la loi de tirage des poids des liens (defaultWeights : entre 1 et weightRange)
+			- r : le générateur aléatoire utilisé pour tous les tirages
 
-		La fonctioneffectue un tirage aléatoire basé sur le temps pour garantir une séquence
-		aléatoire différente à chaque exécution du code. Les nœuds du graphe sont créés avec des
+		La fonction effectue tous ses tirages avec le générateur r : avec la même graine, on obtient
+		exactement le même graphe. Les nœuds du graphe sont créés avec des
 		canaux de messages associés et des noms distincts (R + numéro). Les liens entre les nœuds
 		sont établis de manière aléatoire, en évitant les doublons et les liens avec eux-mêmes (arête boucle).
+		Tous les liens sont créés dans les deux sens, et les parties du graphe qui restent isolées
+		sont ensuite reliées entre elles (voir connectComponents). Aucun routeur ne dépasse
+		maxEdgesPerNode liens.
 
 		Retourne :
 			- Un objet Graph représentant le graphe initialisé
+			- Une erreur si un routeur ne peut pas recevoir minEdgesPerNode liens ou si le graphe ne
+			  peut pas être rendu connexe sans dépasser maxEdgesPerNode liens par routeur
 
 	*/
 
-	rand.Seed(time.Now().UnixNano())
-	//permet d'obtenir une séquence aléatoire differente à chaque execution du code,
-	//on se base sur le temps qui est un parametre qui change constantement
-
 	// On appelle les nodes R + num comme ça il y a pas de confusion avec les poids et on a inf possibilités
-	nodes := make([]*Node, nodesCount)
+	nodes := newRouters(nodesCount)
 
-	for i := 0; i < nodesCount; i++ {
-		channel := make(chan Message)
-		nodes[i] = &Node{Name: fmt.Sprintf("R%d", i+1), Channel: channel}
+	// Un lien est possible vers un autre routeur, pas encore voisin, qui a encore une interface libre
+	free := func(node *Node, otherNode *Node) bool {
+		return node != otherNode && !edgeExists(node, otherNode) && len(otherNode.Edges) < maxEdgesPerNode
 	}
 
 	// Creation liens aléatoirement
 	for _, node := range nodes {
 		// Determiner aléatoirement la quantité d'Edges que le node aura (n entre minEdgesPerNode et maxEdgesPerNode)
-		edgesCount := rand.Intn(maxEdgesPerNode-minEdgesPerNode+1) + minEdgesPerNode
-		if len(node.Edges) < edgesCount {
-			for j := len(node.Edges); j < edgesCount; j++ {
-				// Choisir un node aléatoire
-				otherNode := nodes[rand.Intn(nodesCount)]
-
-				count := 0 // Counter pour éviter une boucle infinie, si on ne trouve pas un node disponible après n/2 essais, on arrete les random
-
-				// Tester si le lien existe déjà et éviter un lien avec lui-même ou avec un routeur qui a toutes les intérfaces occupées
-				for node == otherNode || edgeExists(node, otherNode) || len(otherNode.Edges) >= maxEdgesPerNode {
-					otherNode = nodes[rand.Intn(nodesCount)]
-					count += 1
-					if count > nodesCount/2 && len(node.Edges) >= minEdgesPerNode { //quand on a déjà essayé n/2 fois on arrête de chercher un noeud random
+		edgesCount := r.Intn(maxEdgesPerNode-minEdgesPerNode+1) + minEdgesPerNode
+		for j := len(node.Edges); j < edgesCount; j++ {
+			// Choisir un node aléatoire
+			otherNode := nodes[r.Intn(nodesCount)]
+
+			count := 0 // Counter pour éviter une boucle infinie, si on ne trouve pas un node disponible après n/2 essais, on arrete les random
+			for !free(node, otherNode) && count <= nodesCount/2 {
+				otherNode = nodes[r.Intn(nodesCount)]
+				count += 1
+			}
+			if !free(node, otherNode) {
+				if len(node.Edges) >= minEdgesPerNode {
+					break
+				}
+				// Pas de routeur libre trouvé au hasard : on prend le premier routeur libre du graphe
+				// plutôt que de laisser node avec moins de minEdgesPerNode liens
+				otherNode = nil
+				for _, candidate := range nodes {
+					if free(node, candidate) {
+						otherNode = candidate
 						break
-					} else if count > nodesCount/2 && len(node.Edges) < minEdgesPerNode {
-						for _, otherNode = range nodes {
-							if len(otherNode.Edges) > minEdgesPerNode+1 {
-								otherNode.Edges[0].To = node
-								break
-							}
-						}
-
 					}
-
 				}
-				// Creer le lien dans les deux sens
-				edge := &Edge{To: otherNode, Weight: rand.Intn(weightRange) + 1}
-				node.Edges = append(node.Edges, edge)
-				otherNode.Edges = append(otherNode.Edges, &Edge{To: node, Weight: edge.Weight})
+				if otherNode == nil {
+					return Graph{}, fmt.Errorf("%s n'a que %d liens et aucun autre routeur n'a d'interface libre (-i %d)", node.Name, len(node.Edges), maxEdgesPerNode)
+				}
 			}
+			// Creer le lien dans les deux sens
+			linkNodes(node, otherNode, weights.draw(r))
 		}
 	}
+	if _, err := connectComponents(nodes, maxEdgesPerNode, weights, r); err != nil {
+		return Graph{}, err
+	}
+
+	return Graph{Nodes: nodes}, nil
+}
+
+func newSeed(seed int64) int64 {
+	/*
+		newSeed retourne la graine à utiliser pour la simulation.
+
+		Paramètres :
+			- seed : la graine demandée, 0 si aucune graine n'a été choisie
+
+		Sans graine choisie, on se base sur le temps qui est un paramètre qui change constamment,
+		ce qui donne une séquence aléatoire différente à chaque exécution du code.
+
+		Retourne :
+			- La graine choisie, ou une graine basée sur l'heure
+	*/
+	if seed != 0 {
+		return seed
+	}
+	return time.Now().UnixNano()
+}
 
-	return Graph{Nodes: nodes}
+func randomDestination(g *Graph, nodeSrc *Node) *Node {
+	/*
+		randomDestination tire au hasard, avec le générateur de la simulation, un nœud différent de nodeSrc.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- nodeSrc : le nœud source du trafic
+
+		Retourne :
+			- Un nœud destination différent de nodeSrc
+	*/
+	nodeDst := g.Nodes[rng.Intn(len(g.Nodes))]
+	for nodeDst == nodeSrc {
+		nodeDst = g.Nodes[rng.Intn(len(g.Nodes))]
+	}
+	return nodeDst
 }
 
 func edgeExists(nodeA *Node, nodeB *Node) bool {
@@ -143,12 +279,29 @@ func edgeExists(nodeA *Node, nodeB *Node) bool {
 		Retourne :
 			- Un booléen true si le lien existe, false sinon
 	*/
+	return findEdge(nodeA, nodeB) != nil
+}
+
+func linkWeight(nodeA *Node, nodeB *Node) int {
+	/*
+		linkWeight retourne le poids du lien entre deux noeuds.
+
+		Paramètres :
+			- nodeA : noeud à une extrémité du lien
+			- nodeB : noeud à l'autre extrémité
+
+		Retourne :
+			- Le poids du lien, 0 si le lien n'existe pas
+	*/
+	// Le poids peut être changé pendant que les messages sont relayés (voir changeLinkCostAndRecalculate)
+	nodeA.edgesMu.RLock()
+	defer nodeA.edgesMu.RUnlock()
 	for _, edge := range nodeA.Edges {
 		if edge.To == nodeB {
-			return true
+			return edge.Weight
 		}
 	}
-	return false
+	return 0
 }
 
 //**** TRANSMITION DE MESSAGES	****//
@@ -166,33 +319,59 @@ func sendMessage(messageChan chan Message, messageEnvoye Message) {
 	messageChan <- messageEnvoye
 }
 
-func hello(nodeSrc *Node, nodeDst *Node) {
+func hello(request *helloRequest) {
 	/*
-		hello envoie un message de type "Hello" du nœud source au nœud destination.
+		hello envoie un message de type "Hello" du nœud source au nœud destination de la demande.
 
 		Paramètres :
-			- nodeSrc : Le nœud source à partir duquel le message "Hello" est envoyé.
-			- nodeDst : Le nœud destination auquel le message "Hello" est adressé.
+			- request : La demande Hello, qui donne le nœud source et le nœud destination. Elle est
+			  transportée par le message et acquittée quand la source reçoit le "Hello Ack".
 
 		La fonction utilise la table de routage du nœud source pour déterminer le canal de communication
 		du noeud correspondant au prochain saut vers le nœud destination. Elle crée ensuite un message de type
 		"Hello" avec le nœud source comme émetteur et le nœud destination comme destinataire, puis envoie
-		ce message sur le canal spécifié.
+		ce message sur le canal spécifié. Si la destination est injoignable (réseau coupé en deux par
+		une panne), aucun message n'est envoyé et la demande échoue immédiatement.
 
-		Une fois l'envoi terminé, helloWG.Done() est appelé pour décrémenter le compteur du WaitGroup helloWG.
+		Si le Hello Ack n'est pas reçu dans le délai -hello-retry (Hello ou Hello Ack perdu ou altéré
+		sur un lien), le Hello est renvoyé, au plus -hello-retries fois (voir retransmit).
 
 		La fonction ne retourne rien.
 	*/
-	channel := nodeSrc.RoutingTable[nodeDst.Name]["next_hop"].Channel
-	route := make(map[*Node]struct{})
-	route[nodeSrc] = struct{}{}
-	helloMessage := Message{Source: nodeSrc, Destination: nodeDst, Content: "Hello", Route: route}
-	sendMessage(channel, helloMessage)
+	request.Sent = clockNow()
+	sendHello(request)
+}
+
+func sendHello(request *helloRequest) {
+	/*
+		sendHello envoie un exemplaire du Hello d'une demande, première émission ou retransmission,
+		et programme la retransmission suivante.
+
+		Paramètres :
+			- request : la demande Hello
+
+		La fonction ne retourne rien.
+	*/
+	nodeSrc := request.Source
+	nodeDst := request.Destination
+	if nodeSrc.Failed() {
+		request.fail(nodeSrc.Name + " en panne")
+		return
+	}
+	entry := nodeSrc.Route(nodeDst.Name)
+	if !entry.Reachable() {
+		request.fail("destination injoignable")
+		return
+	}
+	route := []Hop{{Node: nodeSrc, At: clockNow()}}
+	helloMessage := Message{Source: nodeSrc, Destination: nodeDst, Type: MessageHello, TTL: *ttlFlag, Route: route, Payload: request, Flow: request.Flow}
+	// La retransmission est programmée avant l'envoi, qui bloque en mode goroutines
+	request.retransmit()
+	forward(nodeSrc, helloMessage)
 	// fmt.Print("Message Hello envoyé depuis ", nodeSrc.Name, " à destination de ", nodeDst.Name, "\n")
-	helloWG.Done()
 }
 
-func processMessages(g *Graph, node *Node) {
+func processMessages(g *Graph, node *Node, quit <-chan struct{}) {
 	/*
 		processMessages écoute en permanence les messages provenant du canal du nœud spécifié en paramètre
 		et effectue des actions en conséquence selon du contenu du message.
@@ -201,42 +380,57 @@ func processMessages(g *Graph, node *Node) {
 
 			- g : Le graphe global contenant l'ensemble des nœuds
 			- node : Le nœud actuel pour lequel les messages sont traités
+			- quit : fermé quand le routeur tombe en panne (voir failRouter)
 
 		La fonction utilise une boucle infinie pour écouter les messages du canal du nœud en permanence.
-		Lorsqu'un message est reçu, la fonction effectue des actions dépendantes du type de message reçu.
-		La fonction prend en charge les messages de type "Hello", "Hello Ack", "link no longer available",
-		et "new link available". Pour chaque type de message, la fonction fait appel des fonctions spécifiques
-		pour traiter le message.
+		Lorsqu'un message est reçu, la fonction appelle le traitement enregistré pour son type avec
+		registerHandler (voir dispatchMessage). La boucle s'arrête quand le canal est fermé ou quand le
+		routeur tombe en panne.
 
 		La fonction ne retourne rien.
 	*/
 	for {
 		select {
-		case message := <-node.Channel:
-			// fmt.Printf("Le nœud %s a reçu le message '%s' destiné au nœud %s\n", node.Name, message.Content, message.Destination.Name)
-
-			// Actions selon le message reçu
-			switch message.Content {
-			case "Hello":
-				go routing(node, message)
-			case "Hello Ack":
-				go routing(node, message)
-			case "link no longer available":
-				waitGroup.Done()
-				removeLinkAndRecalculate(g, message.LinkDetails) //fonction qui va enlever le lien et recalculer la routing table de tous les routeurs
-			case "new link available":
-				waitGroup.Done()
-				addLinkAndRecalculate(g, message.LinkDetails)
+		case <-quit:
+			return
+		case message, ok := <-node.Channel:
+			if !ok {
+				return
 			}
+			// fmt.Printf("Le nœud %s a reçu le message '%v' destiné au nœud %s\n", node.Name, message.Type, message.Destination.Name)
 
+			// Actions selon le type du message reçu
+			dispatchMessage(g, node, message)
 		}
 	}
 }
 
+// Enregistrement des traitements des messages Hello et des changements de lien
+func init() {
+	registerHandler(MessageHello, "Hello", func(g *Graph, node *Node, message Message) {
+		async(func() { routing(node, message) })
+	})
+	registerHandler(MessageHelloAck, "Hello Ack", func(g *Graph, node *Node, message Message) {
+		async(func() { routing(node, message) })
+	})
+	registerHandler(MessageLinkDown, "link no longer available", func(g *Graph, node *Node, message Message) {
+		waitGroup.Done()
+		removeLinkAndRecalculate(g, message.Payload.(LinkInfo)) //fonction qui va enlever le lien et recalculer la routing table de tous les routeurs
+	})
+	registerHandler(MessageLinkUp, "new link available", func(g *Graph, node *Node, message Message) {
+		waitGroup.Done()
+		addLinkAndRecalculate(g, message.Payload.(LinkInfo))
+	})
+	registerHandler(MessageLinkCost, "link cost changed", func(g *Graph, node *Node, message Message) {
+		waitGroup.Done()
+		changeLinkCostAndRecalculate(g, message.Payload.(LinkInfo))
+	})
+}
+
 func routing(node *Node, received Message) {
 	/*
-			 	routing traite le message reçu (de type Hello ou Hello Ack) en fonction du nœud actuel et
-				du contenu du message.
+			 	routing traite le message reçu (de type Hello, Hello Ack ou notification d'expiration) en
+				fonction du nœud actuel et du contenu du message.
 
 		 		Paramètres :
 		   			- node : Le nœud actuel qui traite le message
@@ -245,46 +439,120 @@ func routing(node *Node, received Message) {
 		 		La fonction examine le contenu du message et le traite selon sa destination et son contenu.
 				Si le message est de type "Hello" et est destiné au nœud actuel, un message "Hello Ack" est
 				envoyé à la source du message initial. Si le message est de type "Hello Ack" et est destiné
-				au nœud actuel, un message est affiché indiquant l'établissement de la liaison entre les nœuds.
-				Pour un message (peu importe son type) qui n'est pas destiné au noeud actuel, le message est
-				transmis au prochain saut déterminé par la table de routage.
+				au nœud actuel, un message est affiché indiquant l'établissement de la liaison entre les nœuds
+				et la demande Hello est acquittée.
+				Si la notification d'un message expiré ou injoignable arrive à la source de ce message, elle
+				est traitée par handleTimeExceeded ou handleDestinationUnreachable.
+				Pour un message (peu importe son type) qui n'est pas destiné au noeud actuel, le TTL est
+				décrémenté : s'il arrive à 0 le message est détruit et sa source est avertie (voir
+				expireInTransit), sinon le message est transmis au prochain saut déterminé par la table de routage.
+
+				Le nœud actuel est ajouté à la fin de la route du message, avec la date d'arrivée et le
+				poids du lien emprunté. Quand le Hello arrive à destination, sa route est enregistrée dans
+				la demande Hello (chemin aller, utilisé par le traceroute). Si le nœud actuel est déjà sur la
+				route, les tables forment une boucle : la première boucle d'un message est affichée et gardée
+				dans le message.
+
+				Un message altéré sur le dernier lien (somme de contrôle fausse) est détruit sans être
+				traité ; si c'est un Hello ou un Hello Ack, la source le renverra (voir retransmit).
 	*/
 
-	received.Route[node] = struct{}{}
+	if received.Corrupted {
+		corruptedMessages.Add(1)
+		return
+	}
+	request, _ := received.Payload.(*helloRequest)
+	previous := received.Route[len(received.Route)-1].Node
+	if received.Loop == nil {
+		if loop := findLoop(received.Route, node); loop != nil {
+			received.Loop = loop
+			reportLoop(node, received, loop)
+		}
+	}
+	received.Route = append(received.Route, Hop{Node: node, At: clockNow(), Weight: linkWeight(previous, node)})
 
-	if received.Destination == node && received.Content == "Hello" {
+	if received.Destination == node && received.Type == MessageHello {
 		// fmt.Print("Hello reçu par ", node.Name, " de la part de ", received.Source.Name, " -- Route: ", afficherRoute(received.Route), "\n")
-		route := make(map[*Node]struct{})
-		route[node] = struct{}{}
-		helloAckMessage := Message{Source: received.Destination, Destination: received.Source, Content: "Hello Ack", Route: route}
-		nodeDst := node.RoutingTable[received.Source.Name]["next_hop"]
-		sendMessage(nodeDst.Channel, helloAckMessage)
+		if request != nil {
+			request.recordPath(received.Route)
+		}
+		route := []Hop{{Node: node, At: clockNow()}}
+		helloAckMessage := Message{Source: received.Destination, Destination: received.Source, Type: MessageHelloAck, TTL: *ttlFlag, Route: route, Payload: request, Flow: received.Flow}
+		forward(node, helloAckMessage)
 		// fmt.Print("helloAck envoyé depuis ", node.Name, " vers ", received.Source.Name, "\n")
 
-	} else if received.Destination == node && received.Content == "Hello Ack" {
+	} else if received.Destination == node && received.Type == MessageHelloAck {
 		fmt.Print(node.Name, " a reçu un message 'Hello Ack' : liaison établie entre les noeuds ", node.Name, " et ", received.Source.Name, "\nRoute : ", afficherRoute(received.Route), "\n")
-		ackReceived++
+		if request != nil {
+			request.acknowledge()
+		}
+	} else if received.Destination == node && received.Type == MessageTimeExceeded {
+		handleTimeExceeded(node, received)
+	} else if received.Destination == node && received.Type == MessageDestinationUnreachable {
+		handleDestinationUnreachable(node, received)
 	} else if received.Destination != node {
-		nodeDst := node.RoutingTable[received.Destination.Name]["next_hop"]
-		sendMessage(nodeDst.Channel, received)
+		received.TTL--
+		if received.TTL <= 0 {
+			expireInTransit(node, received)
+			return
+		}
+		forward(node, received)
+	}
+}
+
+func forward(node *Node, message Message) {
+	/*
+		forward transmet un message au prochain saut vers sa destination, d'après la table de routage du nœud.
+
+		Paramètres :
+			- node : le nœud qui transmet le message
+			- message : le message à transmettre
+
+		Si la table ne donne pas de route vers la destination (réseau coupé en plusieurs parties,
+		protocole pas encore convergé, annonces perdues sur des liens saturés), le message est détruit
+		et sa source en est avertie (voir unreachableInTransit). Si plusieurs chemins ont le même
+		coût, le prochain saut est choisi selon le flux du message (voir nextHopFor). Si le lien vers
+		le prochain saut vient de tomber, le message part par le next hop de secours (voir fastReroute).
+
+		Un message envoyé dans le tunnel d'un LFA distant est d'abord routé vers le routeur PQ, qui le
+		sort du tunnel et le transmet à sa destination.
+
+		La fonction ne retourne rien.
+	*/
+	if message.Tunnel == node {
+		message.Tunnel = nil
+	}
+	target := message.Destination
+	if message.Tunnel != nil {
+		target = message.Tunnel
+	}
+	entry := node.Route(target.Name)
+	if !entry.Reachable() {
+		unreachableInTransit(node, message)
+		return
 	}
+	next := nextHopFor(node, entry, message)
+	if !edgeExists(node, next) {
+		next, message = fastReroute(node, target, next, message)
+	}
+	transmit(node, next, message)
 }
 
-func afficherRoute(route map[*Node]struct{}) string {
+func afficherRoute(route []Hop) string {
 	/*
 			afficherRoute crée une représentation sous forme de chaîne de caractères
 			d'une route spécifiée, en utilisant les noms des nœuds dans l'ordre de la route.
 
 			Paramètre :
-		   		- route : La map représentant la route, avec les nœuds comme clés
+		   		- route : La liste ordonnée des nœuds traversés par le message
 
 		   	Retourne :
 		   		- Une chaîne de caractères représentant la route
 
 	*/
 	var toPrint string
-	for node := range route {
-		toPrint += " " + node.Name + " "
+	for _, hop := range route {
+		toPrint += " " + hop.Node.Name + " "
 	}
 	return toPrint
 }
@@ -299,28 +567,15 @@ func removeLinkAndRecalculate(g *Graph, linkinfo LinkInfo) {
 		   - linkinfo : Les informations sur le lien à supprimer, dont les nœuds reliés par ce lien
 
 		La fonction recherche le lien entre nodeA et nodeB dans les listes d'arêtes des deux
-		nœuds et le supprime. Ensuite, la fonction appelle la fonction constructAllRoutingTables
-		pour recalculer les tables de routage de tous les nœuds du graphe, en prenant en compte
-		la suppression du lien. Enfin, la fonction décrémente le compteur de WaitGroup.
+		nœuds et le supprime. Ensuite, la fonction appelle la fonction recalculateRoutes pour
+		mettre à jour les tables de routage, en prenant en compte la suppression du lien.
+		Enfin, la fonction décrémente le compteur de WaitGroup.
 
 		La fonction ne retourne rien.
 	*/
-	nodeA := linkinfo.NodeA
-	nodeB := linkinfo.NodeB
-	for i, edge := range nodeA.Edges {
-		if edge.To == nodeB {
-			// Eliminer le Edge de la liste de edges de A avec une technique de slicing
-			nodeA.Edges = append(nodeA.Edges[:i], nodeA.Edges[i+1:]...)
-			break
-		}
-	}
-	for i, edge := range nodeB.Edges {
-		if edge.To == nodeA {
-			nodeB.Edges = append(nodeB.Edges[:i], nodeB.Edges[i+1:]...)
-			break
-		}
-	}
-	constructAllRoutingTables(g)
+	removeEdge(linkinfo.NodeA, linkinfo.NodeB)
+	removeEdge(linkinfo.NodeB, linkinfo.NodeA)
+	recalculateRoutes(g, linkinfo, false)
 	waitGroup.Done()
 }
 
@@ -331,47 +586,91 @@ func addLinkAndRecalculate(g *Graph, linkinfo LinkInfo) {
 
 		Paramètres :
 			- g : Le graphe global contenant l'ensemble des nœuds
-			- linkinfo : Les informations sur le lien à ajouter, dont les noeuds reliés par ce lien
-
-		La fonction vérifie d'abord si le lien entre nodeA et nodeB existe déjà. Si le lien
-		n'existe pas, un nouvel Edge est créé pour chaque nœud et ajouté à leur liste d'arêtes.
-		Ensuite, la fonction appelle la fonction constructAllRoutingTables pour recalculer les
-		tables de routage de tous les nœuds du graphe, en prenant en compte l'ajout du nouveau lien.
+			- linkinfo : Les informations sur le lien à ajouter, dont les noeuds reliés par ce lien et
+			  son poids (1 par défaut)
+
+		La fonction vérifie d'abord que le lien peut être ajouté (voir linkRefusal) : il n'existe pas
+		déjà, aucun des deux routeurs n'est en panne et tous deux ont une interface libre. Si c'est le
+		cas, un nouvel Edge est créé pour chaque nœud et ajouté à leur liste d'arêtes.
+		Ensuite, la fonction appelle la fonction recalculateRoutes pour mettre à jour les
+		tables de routage, en prenant en compte l'ajout du nouveau lien.
 		Enfin, la fonction décrémente le compteur du WaitGroup.
 
 		La fonction ne retourne rien.
 	*/
 	nodeA := linkinfo.NodeA
 	nodeB := linkinfo.NodeB
-	// j'ai besoin du poids pour créer le nouveau Edge, je le met dans la classe LinkInfo ou je fais comment?
-
-	linkExists := false
-	for _, edge := range nodeA.Edges {
-		if edge.To == nodeB {
-			linkExists = true
-			break
-		}
+	weight := linkinfo.Weight
+	if weight <= 0 {
+		weight = 1
 	}
-	if !linkExists {
+
+	if refusal := linkRefusal(nodeA, nodeB); refusal != "" {
+		fmt.Printf("Impossible d'ajouter le lien : %s.\n", refusal)
+	} else {
 		// Ajout Edge au node A
-		edgeA := &Edge{To: nodeB, Weight: 1} //j'ai mis 1 par default mais il faudrait plutôt avoir le parametre
-		nodeA.Edges = append(nodeA.Edges, edgeA)
+		addEdge(nodeA, newEdge(nodeB, weight))
 
 		// Ajout Edge au node B
-		edgeB := &Edge{To: nodeA, Weight: 1}
-		nodeB.Edges = append(nodeB.Edges, edgeB)
+		addEdge(nodeB, newEdge(nodeA, weight))
 
 		// Recalcule RoutingTables
-		constructAllRoutingTables(g)
-	} else {
-		fmt.Print("Le lien existait déjà.\n")
+		recalculateRoutes(g, linkinfo, true)
 	}
 	waitGroup.Done()
 }
 
+func changeLinkCostAndRecalculate(g *Graph, linkinfo LinkInfo) {
+	/*
+		changeLinkCostAndRecalculate change le poids d'un lien dans les deux sens, puis met à jour les
+		tables de routage selon le protocole choisi.
+
+		Paramètres :
+			- g : Le graphe global contenant l'ensemble des nœuds
+			- linkinfo : Les deux nœuds du lien et son nouveau poids
+
+		Avec Dijkstra, toutes les tables sont recalculées. Avec les protocoles distribués, seuls les
+		deux routeurs du lien sont prévenus, comme pour un ajout ou une suppression de lien. Rien
+		n'est modifié si le lien n'existe pas ou si le poids n'est pas positif.
+		Enfin, la fonction décrémente le compteur du WaitGroup.
+
+		La fonction ne retourne rien.
+	*/
+	defer waitGroup.Done()
+	nodeA := linkinfo.NodeA
+	nodeB := linkinfo.NodeB
+	edgeA := findEdge(nodeA, nodeB)
+	edgeB := findEdge(nodeB, nodeA)
+	if edgeA == nil || edgeB == nil {
+		fmt.Print("Le lien n'existe pas.\n")
+		return
+	}
+	if linkinfo.Weight <= 0 {
+		fmt.Print("Le poids d'un lien doit être positif.\n")
+		return
+	}
+	nodeA.edgesMu.Lock()
+	edgeA.Weight = linkinfo.Weight
+	nodeA.edgesMu.Unlock()
+	nodeB.edgesMu.Lock()
+	edgeB.Weight = linkinfo.Weight
+	nodeB.edgesMu.Unlock()
+
+	switch *protocolFlag {
+	case protocolDistanceVector:
+		distanceVectorLinkCost(nodeA, nodeB, linkinfo.Weight)
+		distanceVectorLinkCost(nodeB, nodeA, linkinfo.Weight)
+	case protocolLinkState:
+		linkStateLinkCost(nodeA, nodeB, linkinfo.Weight)
+		linkStateLinkCost(nodeB, nodeA, linkinfo.Weight)
+	default:
+		constructAllRoutingTables(g)
+	}
+}
+
 // **** 		FONCTIONS CONSTRUCTION TABLES DE ROUTAGE		****//
 
-func Dijkstra(g *Graph, start *Node) {
+func Dijkstra(g *Graph, start *Node, adj [][]arc, scratch *dijkstraScratch) {
 	/*
 			Dijkstra applique l'algorithme de Dijkstra pour calculer les tables de routage
 			à partir d'un nœud de départ dans le graphe entré en paramètre.
@@ -379,77 +678,180 @@ func Dijkstra(g *Graph, start *Node) {
 			Paramètres :
 		   		- g : Le graphe global contenant l'ensemble des nœuds et des liens
 		   		- start : Le nœud de départ à partir duquel l'algorithme de Dijkstra est lancé
+		   		- adj : La liste d'adjacence du graphe construite par buildAdjacency
+		   		- scratch : Les tableaux de travail du worker, réutilisés d'un appel à l'autre
 
-			La fonction utilise l'algorithme de Dijkstra pour calculer les distances minimales
-			entre le nœud de départ et tous les autres nœuds du graphe. Elle construit ensuite
-			la table de routage du nœud de départ en utilisant les résultats de l'algorithme.
-			Les tables de routage indiquent le prochain nœud (next_hop) sur le chemin le plus
-			court vers chaque destination.
+			La fonction utilise l'algorithme de Dijkstra (avec une file de priorité, voir shortestPaths)
+			pour calculer les distances minimales entre le nœud de départ et tous les autres nœuds du
+			graphe. Elle construit ensuite la table de routage du nœud de départ en utilisant les
+			résultats de l'algorithme. Pour chaque destination, la table indique le prochain nœud
+			(next hop) sur le chemin le plus court, les autres next hops des chemins de même coût, le coût et le nombre de sauts de ce chemin, et le chemin complet (par
+			l'entrée Parent, voir RoutingEntry.Path). La date de mise à jour d'une entrée n'est
+			changée que si son next hop ou son coût a changé depuis le calcul précédent.
 
 			La fonction ne retourne rien.
 	*/
-	unvisited := make(map[*Node]struct{})
-	distances := make(map[*Node]int)
-	next_hop := make(map[*Node]*Node)
-	for _, node := range g.Nodes {
-		if node == start {
-			distances[node] = 0
-			next_hop[node] = node
-		} else {
-			distances[node] = 1<<31 - 1
+	shortestPaths(adj, start.Index, scratch)
+	start.setRoutingTable(buildRoutingTable(start, g.Nodes, scratch))
+}
+
+func buildRoutingTable(start *Node, nodes []*Node, scratch *dijkstraScratch) map[string]*RoutingEntry {
+	/*
+		buildRoutingTable construit la table de routage de start à partir du résultat de shortestPaths.
+
+		Paramètres :
+			- start : le nœud dont on construit la table
+			- nodes : les nœuds dans l'ordre des indices utilisés par shortestPaths
+			- scratch : les tableaux de travail remplis par shortestPaths
+
+		La date de mise à jour d'une entrée n'est changée que si son next hop ou son coût a changé
+		depuis la table précédente de start.
+
+		Retourne :
+			- La nouvelle table de routage, indexée par le nom des destinations
+	*/
+	now := clockNow()
+	previous := start.Table()
+	entries := make([]RoutingEntry, len(nodes)) //une seule allocation pour toutes les entrées de la table
+	table := make(map[string]*RoutingEntry, len(nodes))
+	for i, destNode := range nodes {
+		entry := &entries[i]
+		entry.Source = start
+		entry.Destination = destNode
+		entry.Cost = scratch.Dist[i]
+		entry.HopCount = scratch.Hops[i]
+		if hop := scratch.FirstHop[i]; hop >= 0 {
+			entry.NextHop = nodes[hop]
+		}
+		if hops := scratch.NextHops[i]; len(hops) > 1 {
+			entry.NextHops = make([]*Node, len(hops))
+			for j, hop := range hops {
+				entry.NextHops[j] = nodes[hop]
+			}
 		}
-		unvisited[node] = struct{}{}
+		if parent := scratch.Parent[i]; parent >= 0 {
+			entry.Parent = &entries[parent]
+		}
+		entry.Updated = now
+		if old, ok := previous[destNode.Name]; ok && old.NextHop == entry.NextHop && old.Cost == entry.Cost {
+			entry.Updated = old.Updated
+		}
+		table[destNode.Name] = entry
 	}
+	return table
+}
 
-	for len(unvisited) != 0 {
-		u := minDist(unvisited, distances)
-		if u == nil {
-			break
+func recalculateRoutes(g *Graph, linkinfo LinkInfo, available bool) {
+	/*
+		recalculateRoutes met à jour les tables de routage après l'ajout ou la suppression d'un lien,
+		selon le protocole choisi avec l'option -protocol.
+
+		Paramètres :
+			- g : Le graphe global contenant l'ensemble des nœuds
+			- linkinfo : Les nœuds reliés par le lien modifié
+			- available : true si le lien vient d'être ajouté, false s'il vient d'être supprimé
+
+		Avec Dijkstra, toutes les tables sont recalculées à partir du graphe complet. Avec les protocoles
+		à vecteur de distances et à états de liens, seuls les deux routeurs du lien sont prévenus : les
+		autres apprennent le changement par les annonces échangées (voir waitRoutingConvergence).
+
+		La fonction ne retourne rien.
+	*/
+	switch *protocolFlag {
+	case protocolDistanceVector:
+		if available {
+			if weight := linkWeight(linkinfo.NodeA, linkinfo.NodeB); weight > 0 {
+				distanceVectorLinkUp(linkinfo.NodeA, linkinfo.NodeB, weight)
+				distanceVectorLinkUp(linkinfo.NodeB, linkinfo.NodeA, weight)
+			}
+		} else {
+			distanceVectorLinkDown(linkinfo.NodeA, linkinfo.NodeB)
+			distanceVectorLinkDown(linkinfo.NodeB, linkinfo.NodeA)
 		}
-		delete(unvisited, u)
-		for _, e := range u.Edges {
-			v := e.To
-			alt := distances[u] + e.Weight
-			if alt < distances[v] {
-				distances[v] = alt
-				if u == start {
-					next_hop[v] = v
-				} else {
-					next_hop[v] = next_hop[u]
-				}
+	case protocolLinkState:
+		if available {
+			if weight := linkWeight(linkinfo.NodeA, linkinfo.NodeB); weight > 0 {
+				linkStateLinkUp(linkinfo.NodeA, linkinfo.NodeB, weight)
+				linkStateLinkUp(linkinfo.NodeB, linkinfo.NodeA, weight)
 			}
+		} else {
+			linkStateLinkDown(linkinfo.NodeA, linkinfo.NodeB)
+			linkStateLinkDown(linkinfo.NodeB, linkinfo.NodeA)
 		}
-	}
-	start.RoutingTable = make(map[string]map[string]*Node)
-	for destNode := range distances {
-		start.RoutingTable[destNode.Name] = make(map[string]*Node)
-		start.RoutingTable[destNode.Name]["next_hop"] = next_hop[destNode]
+	default:
+		constructAllRoutingTables(g)
 	}
 }
 
-func minDist(unvisited map[*Node]struct{}, distances map[*Node]int) *Node {
+func startRouting(g *Graph) bool {
 	/*
-		minDist retourne le nœud non visité ayant la distance minimale dans la map de distances.
+		startRouting construit les premières tables de routage avec le protocole choisi par -protocol.
 
 		Paramètres :
-			- unvisited : Une map contenant les nœuds non visités
-			- distances : Une map contenant les distances minimales actuelles pour chaque nœud
+			- g : Le graphe global contenant l'ensemble des nœuds
+
+		Les goroutines processMessages doivent déjà écouter les canaux des nœuds, car les protocoles
+		distribués échangent leurs annonces par ces canaux.
 
 		Retourne :
-			- Le nœud non visité ayant la distance minimale, ou nil si la map est vide
-	*/
-	min := 1<<31 - 1
-	var n *Node
-	for node := range unvisited {
-		if distances[node] < min {
-			min = distances[node]
-			n = node
-		}
+			- false si le protocole est inconnu
+	*/
+	switch *protocolFlag {
+	case protocolDijkstra:
+		constructAllRoutingTables(g)
+	case protocolDistanceVector:
+		startDistanceVector(g)
+	case protocolLinkState:
+		startLinkState(g)
+	default:
+		return false
+	}
+	waitRoutingConvergence(g)
+	return true
+}
+
+func waitRoutingConvergence(g *Graph) {
+	/*
+		waitRoutingConvergence attend que les tables de routage soient stables après un changement
+		de topologie, précalcule les next hops de secours (voir computeBackups), affiche les parties du
+		réseau s'il est coupé (voir reportPartitions), puis exporte la topologie si l'option -dot est
+		utilisée.
+
+		Paramètres :
+			- g : Le graphe global contenant l'ensemble des nœuds
+
+		Avec Dijkstra les tables sont déjà à jour. Avec le protocole à vecteur de distances, on attend
+		qu'aucune route n'ait changé pendant une période d'annonces (au plus 30 périodes). Avec le
+		protocole à états de liens, on attend la fin de l'inondation (au plus lsMaxAge).
+
+		La fonction ne retourne rien.
+	*/
+	switch *protocolFlag {
+	case protocolDistanceVector:
+		dvProtocol.waitConvergence(*dvPeriod, 30**dvPeriod)
+	case protocolLinkState:
+		waitLinkStateConvergence(g, *lsMaxAge)
 	}
-	return n
+	computeBackups(g)
+	reportPartitions(g)
+	dumpDOT(g)
 }
 
-func constructRoutingTablesWorker(jobs <-chan *Node, graph *Graph) {
+func stopRouting() {
+	/*
+		stopRouting arrête les annonces des protocoles distribués avant la fermeture des canaux.
+
+		La fonction ne retourne rien.
+	*/
+	switch *protocolFlag {
+	case protocolDistanceVector:
+		stopDistanceVector()
+	case protocolLinkState:
+		stopLinkState()
+	}
+}
+
+func constructRoutingTablesWorker(jobs <-chan *Node, graph *Graph, adj [][]arc) {
 	/*
 		constructRoutingTablesWorker utilise l'algorithme de Dijkstra pour calculer les tables de
 		routage de tous les noeuds du graphe entré en paramètre.
@@ -457,15 +859,19 @@ func constructRoutingTablesWorker(jobs <-chan *Node, graph *Graph) {
 		Paramètres :
 			- jobs : Un canal (channel) fournissant les nœuds à traiter avec l'algorithme de Dijkstra
 			- graph : Le graphe global contenant l'ensemble des nœuds
+			- adj : La liste d'adjacence du graphe, partagée en lecture seule par les workers
 
 		La fonction reçoit des nœuds à partir du canal "jobs" et applique l'algorithme de Dijkstra
-		à chaque nœud pour calculer les tables de routage correspondantes. La goroutine décrémente
+		à chaque nœud pour calculer les tables de routage correspondantes. Chaque worker prend ses
+		tableaux de travail dans scratchPool et les réutilise pour tous ses nœuds. La goroutine décrémente
 		le compteur du WaitGroup dijWaitGroup après chaque exécution de l'algorithme sur un nœud.
 
 		La fonction ne retourne rien.
 	*/
+	scratch := scratchPool.Get().(*dijkstraScratch)
+	defer scratchPool.Put(scratch)
 	for node := range jobs {
-		Dijkstra(graph, node)
+		Dijkstra(graph, node, adj, scratch)
 		dijWaitGroup.Done()
 	}
 }
@@ -487,13 +893,14 @@ func constructAllRoutingTables(graph *Graph) {
 			Le fonction ne retourne rien.
 	*/
 	start := time.Now()
+	adj := buildAdjacency(graph)
 
 	// Création du canal pour assigner les tâches aux goroutines
-	jobs := make(chan *Node, nodesCount)
+	jobs := make(chan *Node, len(graph.Nodes))
 
 	// Initialisation des goroutines pour construire les tables de routage
 	for i := 0; i < numWorkers; i++ {
-		go constructRoutingTablesWorker(jobs, graph)
+		go constructRoutingTablesWorker(jobs, graph, adj)
 	}
 
 	// Assigner les tâches aux goroutines
@@ -566,165 +973,362 @@ func main() {
 		et teste la communication entre eux en utilisant l'algorithme de Dijkstra pour
 		construire les tables de routage.
 
-		L'utilisateur est invité à définir la taille du graphe, le nombre d'interfaces
-		par routeur, et peut ensuite effectuer différentes actions telles que l'ajout ou
-		la suppression de liens, l'initiation de trafic entre tous les routeurs,
-		l'initialisation de trafic entre deux routeurs au choix ou encore
-		la fermeture de tous les canaux de communication.
+		Sans sous-commande, l'utilisateur est invité à définir la taille du graphe, le nombre
+		d'interfaces par routeur (sauf s'ils sont donnés par -n et -i), et peut ensuite effectuer
+		différentes actions avec le menu interactif (voir interactiveMenu). Les sous-commandes
+		(generate, run, route, ping, fail-link, interactive, voir runCommand) effectuent une action
+		sans aucune saisie, pour les scripts : le code de sortie vaut 0 si l'action a réussi,
+		1 si elle a échoué et 2 si la ligne de commande est invalide.
+
+		Toutes les valeurs aléatoires (graphe et trafic) viennent du générateur rng. Sa graine
+		est affichée au démarrage et peut être redonnée avec -seed (ou "seed" dans le fichier
+		de topologie) pour rejouer une exécution à l'identique.
+
+		Avec l'option -topology, le graphe est chargé depuis un fichier JSON ou YAML
+		au lieu d'être généré aléatoirement. Avec l'option -dot, la topologie est exportée
+		au format DOT au démarrage puis après chaque ajout ou suppression de lien.
+		L'option -protocol dv remplace le calcul centralisé des tables par un protocole à
+		vecteur de distances où chaque routeur n'échange qu'avec ses voisins, et l'option
+		-protocol ls par un protocole à états de liens où chaque routeur calcule ses routes
+		sur sa propre base d'annonces.
 	*/
+	flag.Parse()
+
+	os.Exit(runCommand(flag.Args()))
+}
+
+func buildGraph(prompt bool) (Graph, error) {
+	/*
+		buildGraph crée le graphe de la simulation et initialise les générateurs aléatoires rng et faultRng.
 
-	//Création du graphe et des tables de routage pour chaque noeud
-	fmt.Print("Quelle est la taille N du graphe ? (minimum N = 10) \nN = ")
-	_, err := fmt.Scanln(&nodesCount)
+		Paramètres :
+			- prompt : true pour demander la taille du graphe et le nombre d'interfaces avec des
+			  saisies au clavier s'ils ne sont pas donnés par les options -n et -i
+
+		Le graphe est chargé depuis le fichier de l'option -topology s'il est donné, sinon il est
+		généré avec la forme -shape : par défaut aléatoirement avec -n routeurs d'au plus -i interfaces.
+		Les routeurs des autres formes ont autant d'interfaces que le plus grand degré du graphe
+		généré, ou -i s'il est plus grand, pour pouvoir leur ajouter des liens.
+		Les poids des liens générés suivent la loi -weights.
+
+		Retourne :
+			- Le graphe
+			- Une erreur si la topologie est invalide, si la taille ou la forme du graphe manque ou n'est
+			  pas valide, ou si un taux d'erreur des liens n'est pas une probabilité
+	*/
+	if !isProbability(*lossFlag) || !isProbability(*corruptFlag) || !isProbability(*duplicateFlag) {
+		return Graph{}, fmt.Errorf("les taux -loss, -corrupt et -duplicate doivent être compris entre 0 et 1")
+	}
+	shape, err := parseShape(*shapeFlag)
 	if err != nil {
-		fmt.Println("Error reading input:", err)
-		return
+		return Graph{}, err
+	}
+	weights, err := parseWeights(*weightsFlag)
+	if err != nil {
+		return Graph{}, err
+	}
+	var graph Graph
+	seed := *seedFlag
+	if *topologyPath != "" {
+		if shape.Name != shapeRandom {
+			return Graph{}, fmt.Errorf("-shape et -topology ne peuvent pas être utilisées ensemble")
+		}
+		var topo TopologyFile
+		graph, topo, err = loadTopology(*topologyPath)
+		if err != nil {
+			return Graph{}, fmt.Errorf("chargement de la topologie : %w", err)
+		}
+		nodesCount = len(graph.Nodes)
+		maxEdges = max(topo.MaxInterfaces, maxDegree(&graph))
+		fmt.Printf("Topologie chargée depuis %s : %d routeurs.\n", *topologyPath, nodesCount)
+		if !reportViolations(&graph, "après le chargement") {
+			return Graph{}, fmt.Errorf("la topologie %s ne respecte pas les règles du graphe", *topologyPath)
+		}
+		if seed == 0 {
+			seed = topo.Seed
+		}
+		seed = newSeed(seed)
+		simulationSeed = seed
+		rng = rand.New(rand.NewSource(seed))
+		faultRng = newFaultSource(seed)
+		fmt.Fprintf(os.Stderr, "Graine aléatoire : %d (relancer avec -seed %d pour rejouer cette exécution)\n", seed, seed)
+		return graph, nil
+	}
+
+	nodesCount = *nodesFlag
+	maxEdges = *interfacesFlag
+	if nodesCount == 0 && shape.sized() {
+		if !prompt {
+			return Graph{}, fmt.Errorf("indiquer un fichier de topologie (-topology) ou la taille du graphe (-n et -i)")
+		}
+		fmt.Print("Quelle est la taille N du graphe ? (minimum N = 10) \nN = ")
+		if _, err := fmt.Scanln(&nodesCount); err != nil {
+			return Graph{}, fmt.Errorf("lecture de N : %w", err)
+		}
+	}
+	if shape.Name != shapeRandom {
+		seed = newSeed(seed)
+		simulationSeed = seed
+		rng = rand.New(rand.NewSource(seed))
+		faultRng = newFaultSource(seed)
+		fmt.Fprintf(os.Stderr, "Graine aléatoire : %d (relancer avec -seed %d pour rejouer cette exécution)\n", seed, seed)
+		graph, err = generateGraph(shape, nodesCount, weights, rng)
+		if err != nil {
+			return Graph{}, err
+		}
+		nodesCount = len(graph.Nodes)
+		maxEdges = max(*interfacesFlag, maxDegree(&graph))
+		fmt.Fprintf(os.Stderr, "Graphe %s généré : %d routeurs, jusqu'à %d liens par routeur, poids %v.\n", *shapeFlag, nodesCount, maxEdges, weights)
+		if !reportViolations(&graph, "après la génération") {
+			return Graph{}, fmt.Errorf("le graphe %s généré ne respecte pas les règles du graphe", *shapeFlag)
+		}
+		return graph, nil
 	}
 	if nodesCount < 10 {
-		fmt.Println("Invalid input. N doit être un entier supérieur à 10.")
-		return
+		return Graph{}, fmt.Errorf("N doit être un entier supérieur à 10")
 	}
-	fmt.Print("Combien d'interfaces a chaque routeur ? (minimum i = 3) \ni = ")
-	_, err = fmt.Scanln(&maxEdges)
-	if err != nil {
-		fmt.Println("Error reading input:", err)
-		return
+	if maxEdges == 0 && prompt {
+		fmt.Print("Combien d'interfaces a chaque routeur ? (minimum i = 3) \ni = ")
+		if _, err := fmt.Scanln(&maxEdges); err != nil {
+			return Graph{}, fmt.Errorf("lecture de i : %w", err)
+		}
 	}
 	if maxEdges < 2 {
-		fmt.Println("Invalid input. 'i' doit être supérieur à 2.")
-		return
+		return Graph{}, fmt.Errorf("'i' doit être supérieur à 2")
+	}
+	seed = newSeed(seed)
+	simulationSeed = seed
+	rng = rand.New(rand.NewSource(seed))
+	faultRng = newFaultSource(seed)
+	fmt.Fprintf(os.Stderr, "Graine aléatoire : %d (relancer avec -seed %d pour rejouer cette exécution)\n", seed, seed)
+	graph, err = initRandomGraph(nodesCount, maxEdges, weights, rng)
+	if err != nil {
+		return Graph{}, err
+	}
+	if !reportViolations(&graph, "après la génération") {
+		return Graph{}, fmt.Errorf("le graphe aléatoire généré ne respecte pas les règles du graphe")
+	}
+	return graph, nil
+}
+
+func startNetwork(graph *Graph) error {
+	/*
+		startNetwork lance le traitement des messages de chaque routeur puis construit les tables
+		de routage avec le protocole choisi.
+
+		Paramètres :
+			- graph : le graphe de la simulation
+
+		En mode des, aucune goroutine n'est lancée : les messages sont remis par le moteur de
+		simulation à événements discrets, qui avance quand le programme attend une réponse ou
+		une convergence.
+
+		Retourne :
+			- Une erreur si le mode d'exécution ou le protocole de routage est inconnu
+	*/
+	for i, node := range graph.Nodes {
+		node.Index = i
+	}
+
+	switch *modeFlag {
+	case modeGoroutines:
+		fmt.Print(numWorkers, " CPU\n")
+		traffic = newTrafficTracker()
+		//Lancement des goroutines sur chaque noeud pour process les messages reçus
+		for _, node := range graph.Nodes {
+			node.quit = make(chan struct{})
+			go processMessages(graph, node, node.quit)
+		}
+	case modeDES:
+		fmt.Printf("Simulation à événements discrets (délai des liens : %v).\n", *latencyFlag)
+		engine = newDESEngine(graph)
+	default:
+		return fmt.Errorf("mode inconnu : %s (goroutines ou des)", *modeFlag)
+	}
+	if !startRouting(graph) {
+		return fmt.Errorf("protocole inconnu : %s (dijkstra, dv ou ls)", *protocolFlag)
 	}
-	graph := initRandomGraph(nodesCount, maxEdges)
-	fmt.Print(numWorkers, " CPU\n")
-	constructAllRoutingTables(&graph)
 
 	// Affichage table de routage pour chaque noeud
 	// for _, start := range graph.Nodes {
 	// 	fmt.Println("\nDistances les plus courtes du noeud", start.Name)
-	// 	for dest, route := range start.RoutingTable {
-	// 		fmt.Print(start.Name, " -> ", dest, " : ", route["next_hop"].Name, "\n")
+	// 	for _, route := range start.RoutingTable {
+	// 		fmt.Println(route)
 	// 	}
 	// }
+	return nil
+}
 
-	//Lancement des goroutines sur chaque noeud pour process les messages reçu
-	//et envoyer un Hello Message à un noeud aléatoire
-	for nodeNumber := 0; nodeNumber < nodesCount; nodeNumber++ {
-		helloWG.Add(1) //Incrémentation du wait group pour la goroutine hello
+func stopNetwork(graph *Graph) {
+	/*
+		stopNetwork arrête les protocoles de routage, ferme tous les canaux et affiche les compteurs
+		de messages ignorés, expirés, perdus, altérés ou dupliqués sur les liens.
 
-		nodeSrc := graph.Nodes[nodeNumber]
-		go processMessages(&graph, nodeSrc)
+		Paramètres :
+			- graph : le graphe de la simulation
 
-		nodeDst := graph.Nodes[rand.Intn(nodesCount)]
-		for nodeDst == nodeSrc {
-			nodeDst = graph.Nodes[rand.Intn(nodesCount)]
-		}
-		go hello(nodeSrc, nodeDst)
+		Les retransmissions de Hello programmées sont annulées et les messages encore en transit
+		(exemplaires dupliqués, messages retardés par un lien) sont remis ou abandonnés avant la
+		fermeture des canaux (voir trafficTracker).
+
+		Un second appel est sans effet : un scénario peut fermer les canaux par son événement close
+		avant l'arrêt prévu par la sous-commande.
 
+		La fonction ne retourne rien.
+	*/
+	if graph.stopped {
+		return
 	}
-	helloWG.Add(1)
-	//Incrémentation du wait group pour toutes les goroutines processMessages
-	//On attend que tous les noeuds aient reçu le message Hello Ack pour décrémenter le wait group
-	for ackReceived < nodesCount {
+	graph.stopped = true
+	traffic.shutdown()
+	stopRouting()
+	closeChan(*graph)
+	if engine != nil {
+		engine.report()
 	}
-	helloWG.Done()
-	//Se décrémente quand ackReceived s'est incrémenté jusqu'à atteindre nodesCount,
-	//soit quand tous les messages Hello et Hello Ack ont fini d'être routés
-	helloWG.Wait()
+	if n := unknownMessages.Load(); n > 0 {
+		fmt.Printf("%d messages de type inconnu ont été ignorés.\n", n)
+	}
+	if n := unroutableMessages.Load(); n > 0 {
+		fmt.Printf("%d messages ont été détruits faute de route vers leur destination (%d notifications \"destination unreachable\").\n", n, unreachableNotices.Load())
+	}
+	if n := failedRouterMessages.Load(); n > 0 {
+		fmt.Printf("%d messages ont été perdus sur des routeurs en panne.\n", n)
+	}
+	if n := reroutedMessages.Load(); n > 0 {
+		fmt.Printf("%d messages ont été renvoyés par un next hop de secours (%d par un LFA distant).\n", n, tunneledMessages.Load())
+	}
+	if n := expiredMessages.Load(); n > 0 {
+		fmt.Printf("%d messages ont expiré en transit (%d boucles de routage détectées).\n", n, detectedLoops.Load())
+	}
+	printLinkStatistics(graph, 5)
+	printECMPStatistics(5)
+	printFaultStatistics()
+}
 
+func changeLink(graph *Graph, link LinkInfo, messageType MessageType) {
+	/*
+		changeLink demande l'ajout (MessageLinkUp), la suppression (MessageLinkDown) ou le changement
+		de poids (MessageLinkCost) d'un lien, puis attend que les tables de routage soient stables.
+
+		Paramètres :
+			- graph : le graphe de la simulation
+			- link : les deux routeurs du lien, et son poids pour un ajout ou un changement de poids
+			- messageType : MessageLinkUp, MessageLinkDown ou MessageLinkCost
+
+		Le message est traité par le dernier routeur du graphe qui n'est pas en panne (voir
+		controlRouter), qui modifie le lien et prévient le protocole de routage. Le WaitGroup
+		waitGroup est décrémenté deux fois par ce traitement. Le graphe est ensuite vérifié (voir
+		Validate).
+
+		La fonction ne retourne rien.
+	*/
+	last := controlRouter(graph, nil)
+	message := Message{Source: link.NodeA, Destination: last, Type: messageType, Payload: link}
+	waitGroup.Add(2)
+	if engine != nil {
+		// En mode des, le message de contrôle est traité tout de suite, hors du réseau simulé
+		dispatchMessage(graph, last, message)
+	} else {
+		go sendMessage(last.Channel, message)
+	}
+	waitGroup.Wait()
+	waitRoutingConvergence(graph)
+	reportViolations(graph, fmt.Sprintf("après le message %v", messageType))
+}
+
+func interactiveMenu(graph *Graph) {
+	/*
+		interactiveMenu affiche le menu interactif jusqu'à ce que l'utilisateur choisisse de fermer
+		les canaux : ajout, suppression ou changement de poids de liens (suivis des routes modifiées),
+		trafic, export DOT, tables de routage, LSDB, traceroute, panne et redémarrage de routeurs,
+		vérification du graphe, chemins entre deux routeurs et protection des routes.
+
+		Paramètres :
+			- graph : le graphe de la simulation, dont les protocoles sont déjà démarrés
+
+		La fonction ne retourne rien, à la fin de l'entrée standard si aucune commande n'a fermé les
+		canaux. Les canaux sont fermés par l'appelant (voir stopNetwork).
+	*/
 	//Boucle infinie pour que l'utilisateur puisse agir sur le graphe:
 	//ajout ou suppression de liens, fermeture de tous les canaux
 	for {
 
 		var commande int
-		fmt.Print("\n1 - Pour ajouter un lien au graphe.\n2 - Pour supprimer un lien existant.\n3 - Pour initier du traffic dans le graphe actuel.\n4 - Pour initier du traffic entre deux routeurs.\n5 - Pour fermer tous les canaux de communication.\nCommande 1, 2, 3, 4 ou 5 : ")
-		fmt.Scanln(&commande)
+		fmt.Print("\n1 - Pour ajouter un lien au graphe.\n2 - Pour supprimer un lien existant.\n3 - Pour initier du traffic dans le graphe actuel.\n4 - Pour initier du traffic entre deux routeurs.\n5 - Pour fermer tous les canaux de communication.\n6 - Pour exporter le graphe au format DOT.\n7 - Pour afficher la table de routage d'un routeur.\n8 - Pour afficher la route entre deux routeurs.\n9 - Pour comparer les bases d'états de liens (-protocol ls).\n10 - Pour lancer un traceroute entre deux routeurs.\n11 - Pour mettre un routeur en panne.\n12 - Pour redémarrer un routeur en panne.\n13 - Pour changer le poids d'un lien.\n14 - Pour vérifier le graphe.\n15 - Pour lister les plus courts chemins et les chemins disjoints entre deux routeurs.\n16 - Pour afficher la protection des routes (LFA).\nCommande 1 à 16 : ")
+		if _, err := fmt.Scanln(&commande); err == io.EOF {
+			//Plus aucune commande ne peut être lue
+			return
+		}
 
 		if commande == 1 {
 			//Ajout d'un lien
-			var num1, num2 int
 			fmt.Printf("\n\n\nVeuillez saisir un numéro de routeur : \nR")
-			fmt.Scanln(&num1)
-			for num1 < 1 || num1 > nodesCount {
-				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
-				fmt.Scanln(&num1)
+			nodeA, ok := scanRouter(graph, func(node *Node) string {
+				if node.Failed() {
+					return fmt.Sprintf("%s est en panne", node.Name)
+				}
+				if len(node.Edges) >= maxEdges {
+					return fmt.Sprintf("%s n'a plus d'interface libre (%d liens)", node.Name, len(node.Edges))
+				}
+				return ""
+			})
+			if !ok {
+				continue
 			}
 			fmt.Printf("\nVoici les voisins du routeur choisi :\n- ")
-			nodeA := graph.Nodes[num1-1]
 
 			for _, edge := range nodeA.Edges {
 				fmt.Print(edge.To.Name, " - ")
 			}
 			fmt.Printf("\n\nVeuillez choisir le numéro d'un routeur qui n'est pas voisin de %s :\nR", nodeA.Name)
-			fmt.Scanln(&num2)
-			for num2 < 1 || num2 > nodesCount {
-				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
-				fmt.Scanln(&num2)
+			nodeB, ok := scanRouter(graph, func(node *Node) string { return linkRefusal(nodeA, node) })
+			if !ok {
+				continue
 			}
-			nodeB := graph.Nodes[num2-1]
+			weight := readLinkWeight()
 
-			link_details := LinkInfo{NodeA: nodeA, NodeB: nodeB}
-			link_creation := Message{Source: nodeA, Destination: graph.Nodes[nodesCount-1], Content: "new link available", LinkDetails: link_details}
-			waitGroup.Add(2)
-			go sendMessage(graph.Nodes[nodesCount-1].Channel, link_creation)
-			go processMessages(&graph, graph.Nodes[nodesCount-1])
-			waitGroup.Wait()
+			before := snapshotRoutingTables(graph)
+			changeLink(graph, LinkInfo{NodeA: nodeA, NodeB: nodeB, Weight: weight}, MessageLinkUp)
+			reportRoutingDiff(graph, before, fmt.Sprintf("Ajout du lien %s - %s", nodeA.Name, nodeB.Name), 20)
 
 		} else if commande == 2 {
 			//Suppression d'un lien
-			var num1, num2 int
 			fmt.Printf("\n\n\nVeuillez saisir un numéro de routeur : \nR")
-			fmt.Scanln(&num1)
-			for num1 < 1 || num1 > nodesCount || len(graph.Nodes[num1-1].Edges) == maxEdges {
-				fmt.Printf("Saisie non valide. Le routeur n'existe pas ou ses interfaces sont déjà complètes.\nVeuillez saisir un numéro de routeur : \nR")
-				fmt.Scanln(&num1)
+			nodeA, ok := scanRouter(graph, func(node *Node) string {
+				if len(node.Edges) == 0 {
+					return fmt.Sprintf("%s n'a aucun lien", node.Name)
+				}
+				return ""
+			})
+			if !ok {
+				continue
 			}
 			fmt.Printf("\nVoici les voisins du routeur choisi :\n- ")
-			nodeA := graph.Nodes[num1-1]
 
 			for _, edge := range nodeA.Edges {
 				fmt.Print(edge.To.Name, " - ")
 			}
 			fmt.Printf("\n\nVeuillez choisir le numéro d'un routeur voisin de %s :\nR", nodeA.Name)
-			fmt.Scanln(&num2)
-			for num2 < 1 || num2 > nodesCount || len(graph.Nodes[num1-1].Edges) == maxEdges {
-				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
-				fmt.Scanln(&num2)
+			nodeB, ok := scanRouter(graph, func(node *Node) string {
+				if !edgeExists(nodeA, node) {
+					return fmt.Sprintf("%s n'est pas voisin de %s", node.Name, nodeA.Name)
+				}
+				return ""
+			})
+			if !ok {
+				continue
 			}
-			nodeB := graph.Nodes[num2-1]
 
-			link_details := LinkInfo{NodeA: nodeA, NodeB: nodeB}
-			link_failure := Message{Source: nodeA, Destination: graph.Nodes[nodesCount-1], Content: "link no longer available", LinkDetails: link_details}
-			waitGroup.Add(2)
-			go sendMessage(graph.Nodes[nodesCount-1].Channel, link_failure)
-			go processMessages(&graph, graph.Nodes[nodesCount-1])
-			waitGroup.Wait()
+			before := snapshotRoutingTables(graph)
+			changeLink(graph, LinkInfo{NodeA: nodeA, NodeB: nodeB}, MessageLinkDown)
+			reportRoutingDiff(graph, before, fmt.Sprintf("Suppression du lien %s - %s", nodeA.Name, nodeB.Name), 20)
 
 		} else if commande == 3 {
-			//Lancement des goroutines sur chaque noeud pour process les messages reçu
-			//et envoyer un Hello Message à un noeud aléatoire
-			for nodeNumber := 0; nodeNumber < nodesCount; nodeNumber++ {
-				helloWG.Add(1) //Incrémentation du wait group pour la goroutine hello
-
-				nodeSrc := graph.Nodes[nodeNumber]
-				go processMessages(&graph, nodeSrc)
-
-				nodeDst := graph.Nodes[rand.Intn(nodesCount)]
-				for nodeDst == nodeSrc {
-					nodeDst = graph.Nodes[rand.Intn(nodesCount)]
-				}
-				go hello(nodeSrc, nodeDst)
-
-			}
-			helloWG.Add(1)
-			//Incrémentation du wait group pour toutes les goroutines processMessages
-			//On attend que tous les noeuds aient reçu le message Hello Ack pour décrémenter le wait group
-			for ackReceived < nodesCount {
-			}
-			helloWG.Done()
-			//Se décrémente quand ackReceived s'est incrémenté jusqu'à atteindre nodesCount,
-			//soit quand tous les messages Hello et Hello Ack ont fini d'être routés
-			helloWG.Wait()
-			time.Sleep(2 * time.Second)
+			//Envoi d'un Hello Message à un noeud aléatoire depuis chaque noeud
+			requests := randomHelloRequests(graph)
+			helloRound(requests, *helloTimeout)
+			printHelloLatency(requests)
 
 		} else if commande == 4 {
 			var num1, num2 int
@@ -744,35 +1348,244 @@ func main() {
 			}
 			nodeB := graph.Nodes[num2-1]
 
-			for nodeNumber := 0; nodeNumber < nodesCount; nodeNumber++ {
-
-				nodeSrc := graph.Nodes[nodeNumber]
-				go processMessages(&graph, nodeSrc)
-
-			}
-			helloWG.Add(2)
-			go hello(nodeA, nodeB)
-			//Incrémentation du wait group pour toutes les goroutines processMessages
-			//On attend que tous les noeuds aient reçu le message Hello Ack pour décrémenter le wait group
-			for ackReceived < 1 {
-			}
-			helloWG.Done()
-			//Se décrémente quand ackReceived s'est incrémenté jusqu'à atteindre nodesCount,
-			//soit quand tous les messages Hello et Hello Ack ont fini d'être routés
-			helloWG.Wait()
-			time.Sleep(2 * time.Second)
+			helloRound([]*helloRequest{newHelloRequest(nodeA, nodeB)}, *helloTimeout)
 
 		} else if commande == 5 {
 			break
+		} else if commande == 6 {
+			//Export DOT du graphe actuel
+			var path string
+			var num int
+			fmt.Printf("\n\n\nNom du fichier à créer : ")
+			fmt.Scanln(&path)
+			for path == "" {
+				fmt.Printf("Saisie non valide.\nNom du fichier à créer : ")
+				fmt.Scanln(&path)
+			}
+			fmt.Printf("\nNuméro du routeur dont l'arbre des plus courts chemins doit être mis en évidence (0 pour aucun) : \nR")
+			fmt.Scanln(&num)
+			for num < 0 || num > nodesCount {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num)
+			}
+			var root *Node
+			if num > 0 {
+				root = graph.Nodes[num-1]
+			}
+			if err := writeDOTFile(path, graph, root); err != nil {
+				fmt.Println("Export DOT impossible :", err)
+			} else {
+				fmt.Printf("Graphe exporté dans %s.\n", path)
+			}
+		} else if commande == 7 {
+			//Affichage de la table de routage d'un routeur
+			var num int
+			fmt.Printf("\n\n\nVeuillez saisir un numéro de routeur : \nR")
+			fmt.Scanln(&num)
+			for num < 1 || num > nodesCount {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num)
+			}
+			printRoutingTable(graph.Nodes[num-1])
+		} else if commande == 8 {
+			//Affichage de la route entre deux routeurs
+			var num1, num2 int
+			fmt.Printf("\n\n\nVeuillez saisir le numéro du routeur source : \nR")
+			fmt.Scanln(&num1)
+			for num1 < 1 || num1 > nodesCount {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num1)
+			}
+			fmt.Printf("\nVeuillez saisir le numéro du routeur destination : \nR")
+			fmt.Scanln(&num2)
+			for num2 < 1 || num2 > nodesCount || num2 == num1 {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num2)
+			}
+			fmt.Println()
+			printRoute(graph.Nodes[num1-1], graph.Nodes[num2-1])
+		} else if commande == 9 {
+			//Comparaison des LSDB du protocole à états de liens
+			if *protocolFlag != protocolLinkState {
+				fmt.Print("\nLes bases d'états de liens n'existent qu'avec -protocol ls.\n")
+				continue
+			}
+			var num int
+			fmt.Printf("\n\n\nNuméro du routeur dont la LSDB doit être affichée : \nR")
+			fmt.Scanln(&num)
+			for num < 1 || num > nodesCount {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num)
+			}
+			fmt.Println()
+			printLinkStateDatabases(graph, graph.Nodes[num-1])
+		} else if commande == 10 {
+			//Traceroute entre deux routeurs
+			var num1, num2 int
+			fmt.Printf("\n\n\nVeuillez saisir le numéro du routeur source : \nR")
+			fmt.Scanln(&num1)
+			for num1 < 1 || num1 > nodesCount {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num1)
+			}
+			fmt.Printf("\nVeuillez saisir le numéro du routeur destination : \nR")
+			fmt.Scanln(&num2)
+			for num2 < 1 || num2 > nodesCount || num2 == num1 {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num2)
+			}
+			traceroute(graph, graph.Nodes[num1-1], graph.Nodes[num2-1])
+		} else if commande == 11 {
+			//Panne d'un routeur et de tous ses liens
+			var num int
+			fmt.Printf("\n\n\nNuméro du routeur à mettre en panne : \nR")
+			fmt.Scanln(&num)
+			for num < 1 || num > nodesCount || graph.Nodes[num-1].Failed() {
+				fmt.Printf("Saisie non valide. Le routeur n'existe pas ou est déjà en panne.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num)
+			}
+			before := snapshotRoutingTables(graph)
+			changeRouter(graph, graph.Nodes[num-1], MessageRouterDown)
+			reportRoutingDiff(graph, before, fmt.Sprintf("Panne de %s", graph.Nodes[num-1].Name), 20)
+		} else if commande == 12 {
+			//Redémarrage d'un routeur avec ses liens d'origine
+			failed := failedRouters(graph)
+			if len(failed) == 0 {
+				fmt.Print("\nAucun routeur n'est en panne.\n")
+				continue
+			}
+			fmt.Printf("\nRouteurs en panne :\n- ")
+			for _, node := range failed {
+				fmt.Print(node.Name, " - ")
+			}
+			var num int
+			fmt.Printf("\n\nNuméro du routeur à redémarrer : \nR")
+			fmt.Scanln(&num)
+			for num < 1 || num > nodesCount || !graph.Nodes[num-1].Failed() {
+				fmt.Printf("Saisie non valide. Le routeur n'existe pas ou n'est pas en panne.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num)
+			}
+			before := snapshotRoutingTables(graph)
+			changeRouter(graph, graph.Nodes[num-1], MessageRouterUp)
+			reportRoutingDiff(graph, before, fmt.Sprintf("Redémarrage de %s", graph.Nodes[num-1].Name), 20)
+		} else if commande == 13 {
+			//Changement du poids d'un lien
+			var num1, num2 int
+			fmt.Printf("\n\n\nVeuillez saisir un numéro de routeur : \nR")
+			fmt.Scanln(&num1)
+			for num1 < 1 || num1 > nodesCount || len(graph.Nodes[num1-1].Edges) == 0 {
+				fmt.Printf("Saisie non valide. Le routeur n'existe pas ou n'a aucun lien.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num1)
+			}
+			nodeA := graph.Nodes[num1-1]
+			fmt.Printf("\nVoici les voisins du routeur choisi (poids du lien) :\n- ")
+			for _, edge := range nodeA.Edges {
+				fmt.Printf("%s (%d) - ", edge.To.Name, edge.Weight)
+			}
+			fmt.Printf("\n\nVeuillez choisir le numéro d'un routeur voisin de %s :\nR", nodeA.Name)
+			fmt.Scanln(&num2)
+			for num2 < 1 || num2 > nodesCount || !edgeExists(nodeA, graph.Nodes[num2-1]) {
+				fmt.Printf("Saisie non valide. Le routeur n'est pas voisin de %s.\nVeuillez saisir un numéro de routeur : \nR", nodeA.Name)
+				fmt.Scanln(&num2)
+			}
+			nodeB := graph.Nodes[num2-1]
+			weight := readLinkWeight()
+
+			before := snapshotRoutingTables(graph)
+			changeLink(graph, LinkInfo{NodeA: nodeA, NodeB: nodeB, Weight: weight}, MessageLinkCost)
+			reportRoutingDiff(graph, before, fmt.Sprintf("Poids du lien %s - %s changé en %d", nodeA.Name, nodeB.Name, weight), 20)
+		} else if commande == 14 {
+			//Vérification des invariants du graphe
+			if reportViolations(graph, "à la vérification") {
+				fmt.Printf("\nGraphe valide : %d routeurs, liens symétriques, au plus %d interfaces par routeur.\n", len(graph.Nodes), maxEdges)
+			}
+		} else if commande == 15 {
+			//Plus courts chemins et chemins disjoints entre deux routeurs
+			var num1, num2, k int
+			fmt.Printf("\n\n\nVeuillez saisir le numéro du routeur source : \nR")
+			fmt.Scanln(&num1)
+			for num1 < 1 || num1 > nodesCount {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num1)
+			}
+			fmt.Printf("\nVeuillez saisir le numéro du routeur destination : \nR")
+			fmt.Scanln(&num2)
+			for num2 < 1 || num2 > nodesCount || num2 == num1 {
+				fmt.Printf("Saisie non valide.\nVeuillez saisir un numéro de routeur : \nR")
+				fmt.Scanln(&num2)
+			}
+			fmt.Printf("\nNombre de plus courts chemins à afficher : ")
+			fmt.Scanln(&k)
+			for k < 1 {
+				fmt.Printf("Saisie non valide.\nNombre de chemins : ")
+				fmt.Scanln(&k)
+			}
+			fmt.Println()
+			printPathDiversity(graph, graph.Nodes[num1-1], graph.Nodes[num2-1], k)
+		} else if commande == 16 {
+			//Couverture des next hops de secours, puis secours d'un routeur
+			var num int
+			fmt.Println()
+			printBackupCoverage(graph, 10)
+			fmt.Printf("\nNuméro du routeur dont les secours doivent être affichés (0 pour aucun) : \nR")
+			fmt.Scanln(&num)
+			if num >= 1 && num <= nodesCount {
+				printBackups(graph.Nodes[num-1])
+			}
 		} else {
 			var dummyInt int
 			var dummyStr string   // Variable pour vider le buffer
 			fmt.Scanln(&dummyInt) // On lit s'il reste quelque chose dans le buffer
 			fmt.Scanln(&dummyStr)
-			fmt.Print("\nSaisie incorrecte.\nVeillez à entrer 1, 2, 3 ou 4\n")
+			fmt.Print("\nSaisie incorrecte.\nVeillez à entrer un nombre entre 1 et 16\n")
+
+		}
+	}
+}
+
+func scanRouter(graph *Graph, refusal func(node *Node) string) (*Node, bool) {
+	/*
+		scanRouter demande au clavier un numéro de routeur, jusqu'à obtenir un routeur du graphe
+		accepté par refusal. La saisie est abandonnée si elle ne peut pas être lue (fin de l'entrée
+		standard ou saisie qui n'est pas un entier), pour ne pas redemander sans fin le numéro.
+
+		Paramètres :
+			- graph : le graphe de la simulation
+			- refusal : retourne la raison pour laquelle un routeur ne convient pas, vide s'il convient
 
+		Retourne :
+			- Le routeur choisi
+			- false si la saisie a été abandonnée
+	*/
+	for {
+		var num int
+		if _, err := fmt.Scanln(&num); err != nil {
+			fmt.Printf("\nSaisie abandonnée : %v.\n", err)
+			return nil, false
+		}
+		if num < 1 || num > len(graph.Nodes) {
+			fmt.Printf("Saisie non valide : R%d n'existe pas.\nVeuillez saisir un numéro de routeur : \nR", num)
+		} else if reason := refusal(graph.Nodes[num-1]); reason != "" {
+			fmt.Printf("Saisie non valide : %s.\nVeuillez saisir un numéro de routeur : \nR", reason)
+		} else {
+			return graph.Nodes[num-1], true
 		}
 	}
-	closeChan(graph)
+}
 
+func readLinkWeight() int {
+	/*
+		readLinkWeight demande au clavier le poids d'un lien, jusqu'à obtenir un entier positif.
+
+		Retourne :
+			- Le poids saisi
+	*/
+	var weight int
+	fmt.Printf("\nPoids du lien (entier positif) : ")
+	fmt.Scanln(&weight)
+	for weight < 1 {
+		fmt.Printf("Saisie non valide.\nPoids du lien : ")
+		fmt.Scanln(&weight)
+	}
+	return weight
 }
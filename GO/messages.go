@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+//**** TYPES DE MESSAGES ET TRAITEMENT ****//
+
+// Type d'un message, qui détermine la fonction appelée à sa réception
+type MessageType int
+
+const (
+	MessageHello                  MessageType = iota + 1 //demande de liaison envoyée à un routeur éloigné
+	MessageHelloAck                                      //réponse au Hello
+	MessageLinkDown                                      //lien supprimé (anciennement "link no longer available")
+	MessageLinkUp                                        //lien ajouté (anciennement "new link available")
+	MessageDistanceVector                                //vecteur de distances envoyé à un voisin
+	MessageLinkState                                     //annonce d'états de liens relayée à un voisin
+	MessageTimeExceeded                                  //notification envoyée à la source d'un message expiré en transit
+	MessageRouterDown                                    //routeur tombé en panne
+	MessageRouterUp                                      //routeur redémarré
+	MessageLinkCost                                      //poids d'un lien modifié
+	MessageDestinationUnreachable                        //notification envoyée à la source d'un message qu'un routeur n'a pas pu transmettre
+)
+
+// Contenu spécifique à un type de message (demande Hello, lien modifié, annonce de routage...)
+type Payload interface {
+	payload()
+	size() int //taille du contenu en octets, qui détermine le temps d'émission sur un lien
+}
+
+func (*helloRequest) payload()           {}
+func (LinkInfo) payload()                {}
+func (DistanceVectorUpdate) payload()    {}
+func (*LinkStateAdvertisement) payload() {}
+func (*TimeExceeded) payload()           {}
+func (RouterInfo) payload()              {}
+func (*DestinationUnreachable) payload() {}
+
+func (*helloRequest) size() int                       { return 8 }
+func (LinkInfo) size() int                            { return 8 }
+func (u DistanceVectorUpdate) size() int              { return 8 * len(u) }
+func (lsa *LinkStateAdvertisement) size() int         { return 16 + 8*len(lsa.Links) }
+func (expired *TimeExceeded) size() int               { return 16 + 8*len(expired.Route) }
+func (RouterInfo) size() int                          { return 8 }
+func (unreachable *DestinationUnreachable) size() int { return 16 + 8*len(unreachable.Route) }
+
+// Fonction appelée par processMessages à la réception d'un message d'un type donné
+type messageHandler func(g *Graph, node *Node, message Message)
+
+// Traitement enregistré pour un type de message
+type messageKind struct {
+	Name    string
+	Handler messageHandler
+}
+
+// Variables globales du traitement des messages //
+var messageKinds = make(map[MessageType]messageKind) //rempli par les fonctions init avant le lancement des goroutines
+var unknownMessages atomic.Int64                     //messages reçus dont le type n'a pas de traitement
+
+func registerHandler(messageType MessageType, name string, handler messageHandler) {
+	/*
+		registerHandler enregistre la fonction qui traite un type de message. Pour ajouter un nouveau
+		type de message, il suffit de déclarer sa constante et d'appeler registerHandler dans une
+		fonction init.
+
+		Paramètres :
+			- messageType : le type de message
+			- name : le nom du type, utilisé dans les affichages
+			- handler : la fonction appelée par processMessages à la réception d'un message de ce type
+
+		La fonction ne retourne rien. Elle panique si le type est déjà enregistré, ce qui signale
+		deux traitements en conflit dès le démarrage du programme.
+	*/
+	if _, exists := messageKinds[messageType]; exists {
+		panic(fmt.Sprintf("type de message %d enregistré deux fois", messageType))
+	}
+	messageKinds[messageType] = messageKind{Name: name, Handler: handler}
+}
+
+func (messageType MessageType) String() string {
+	/*
+		String retourne le nom du type de message, tel qu'il a été enregistré.
+
+		Retourne :
+			- Le nom du type, ou "inconnu (n)" s'il n'a pas été enregistré
+	*/
+	if kind, ok := messageKinds[messageType]; ok {
+		return kind.Name
+	}
+	return fmt.Sprintf("inconnu (%d)", int(messageType))
+}
+
+func dispatchMessage(g *Graph, node *Node, message Message) {
+	/*
+		dispatchMessage appelle la fonction enregistrée pour le type du message reçu.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- node : le nœud qui a reçu le message
+			- message : le message reçu
+
+		Un message dont le type n'a pas de traitement est compté et signalé au lieu d'être ignoré
+		silencieusement. Un message arrivé sur un routeur en panne est perdu (voir dropAtFailedRouter).
+
+		La fonction ne retourne rien.
+	*/
+	if node.Failed() {
+		dropAtFailedRouter(node, message)
+		return
+	}
+	kind, ok := messageKinds[message.Type]
+	if !ok {
+		unknownMessages.Add(1)
+		sourceName := "?"
+		if message.Source != nil {
+			sourceName = message.Source.Name
+		}
+		fmt.Printf("%s a reçu un message de type %v de %s : message ignoré.\n", node.Name, message.Type, sourceName)
+		return
+	}
+	kind.Handler(g, node, message)
+}
@@ -0,0 +1,540 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//**** K PLUS COURTS CHEMINS ET CHEMINS DISJOINTS ****//
+
+// Chemin entre deux routeurs avec son coût
+type weightedPath struct {
+	Nodes []*Node //routeurs du chemin, de la source à la destination incluses
+	Cost  int     //somme des poids des liens du chemin
+}
+
+// Chemin sur les indices de la liste d'adjacence, utilisé pendant les calculs
+type indexPath struct {
+	Nodes []int
+	Cost  int
+}
+
+// Sorte de chemins disjoints cherchés par DisjointPaths
+type disjointKind int
+
+const (
+	linkDisjoint disjointKind = iota //aucun lien en commun
+	nodeDisjoint                     //aucun routeur en commun, sauf la source et la destination
+)
+
+// Arc du réseau de flot de DisjointPaths, avec l'indice de l'arc inverse dans la liste de To
+type flowArc struct {
+	To       int
+	Capacity int //capacité restante
+	Initial  int //capacité de départ, 0 pour les arcs inverses
+	Cost     int
+	Reverse  int
+}
+
+func constrainedPath(adj [][]arc, src int, dst int, bannedNodes []bool, bannedArcs map[[2]int]bool) indexPath {
+	/*
+		constrainedPath cherche le plus court chemin de src à dst avec Dijkstra, sans passer par
+		certains routeurs ni certains liens.
+
+		Paramètres :
+			- adj : la liste d'adjacence construite par buildAdjacency
+			- src, dst : les indices de la source et de la destination
+			- bannedNodes : les routeurs interdits (nil si aucun), src ne doit pas en faire partie
+			- bannedArcs : les liens interdits, dans le sens (de, vers) (nil si aucun)
+
+		En cas d'égalité, le premier chemin trouvé est gardé, comme dans shortestPaths.
+
+		Retourne :
+			- Le chemin et son coût, avec Nodes à nil si dst est injoignable
+	*/
+	n := len(adj)
+	dist := make([]int, n)
+	parent := make([]int, n)
+	for i := range dist {
+		dist[i] = infiniteDistance
+		parent[i] = -1
+	}
+	visited := make([]bool, n)
+	dist[src] = 0
+	queue := distanceQueue{{Node: src, Dist: 0}}
+	for queue.Len() > 0 {
+		item := heap.Pop(&queue).(queueItem)
+		u := item.Node
+		if visited[u] || item.Dist > dist[u] {
+			continue
+		}
+		visited[u] = true
+		if u == dst {
+			break
+		}
+		for _, a := range adj[u] {
+			if (bannedNodes != nil && bannedNodes[a.To]) || bannedArcs[[2]int{u, a.To}] {
+				continue
+			}
+			if alt := dist[u] + a.Weight; alt < dist[a.To] {
+				dist[a.To] = alt
+				parent[a.To] = u
+				heap.Push(&queue, queueItem{Node: a.To, Dist: alt})
+			}
+		}
+	}
+	if dist[dst] == infiniteDistance {
+		return indexPath{Cost: infiniteDistance}
+	}
+	var path []int
+	for v := dst; v != -1; v = parent[v] {
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return indexPath{Nodes: path, Cost: dist[dst]}
+}
+
+func arcWeight(adj [][]arc, u int, v int) int {
+	/*
+		arcWeight donne le poids du lien de u vers v.
+
+		Paramètres :
+			- adj : la liste d'adjacence
+			- u, v : les indices des extrémités du lien
+
+		Retourne :
+			- Le poids du lien, infiniteDistance s'il n'existe pas
+	*/
+	for _, a := range adj[u] {
+		if a.To == v {
+			return a.Weight
+		}
+	}
+	return infiniteDistance
+}
+
+func samePrefix(path []int, prefix []int) bool {
+	/*
+		samePrefix indique si un chemin commence par les nœuds d'un autre.
+
+		Paramètres :
+			- path : le chemin
+			- prefix : le début de chemin cherché
+
+		Retourne :
+			- true si path commence par prefix
+	*/
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func shorterPath(a indexPath, b indexPath) bool {
+	/*
+		shorterPath ordonne deux chemins : par coût, puis par nombre de sauts, puis par indices des
+		routeurs traversés, pour que le résultat ne dépende pas de l'ordre de calcul.
+
+		Paramètres :
+			- a, b : les chemins à comparer
+
+		Retourne :
+			- true si a passe avant b
+	*/
+	if a.Cost != b.Cost {
+		return a.Cost < b.Cost
+	}
+	if len(a.Nodes) != len(b.Nodes) {
+		return len(a.Nodes) < len(b.Nodes)
+	}
+	for i := range a.Nodes {
+		if a.Nodes[i] != b.Nodes[i] {
+			return a.Nodes[i] < b.Nodes[i]
+		}
+	}
+	return false
+}
+
+func yenPaths(adj [][]arc, src int, dst int, k int) []indexPath {
+	/*
+		yenPaths applique l'algorithme de Yen : les k plus courts chemins sans boucle de src à dst.
+
+		Paramètres :
+			- adj : la liste d'adjacence construite par buildAdjacency
+			- src, dst : les indices de la source et de la destination
+			- k : le nombre de chemins cherchés
+
+		Chaque nouveau chemin est cherché en déviant du précédent à chacun de ses routeurs (le
+		routeur de déviation) : le début du chemin jusqu'à ce routeur est gardé, ses autres routeurs
+		sont interdits, ainsi que les liens qui suivent ce même début dans les chemins déjà trouvés,
+		et la fin est calculée par constrainedPath. Le meilleur de tous les candidats devient le
+		chemin suivant.
+
+		Retourne :
+			- Au plus k chemins, du plus court au plus long
+	*/
+	first := constrainedPath(adj, src, dst, nil, nil)
+	if first.Nodes == nil || k < 1 {
+		return nil
+	}
+	found := []indexPath{first}
+	var candidates []indexPath
+	known := func(path []int) bool {
+		for _, paths := range [][]indexPath{found, candidates} {
+			for _, p := range paths {
+				if len(p.Nodes) == len(path) && samePrefix(p.Nodes, path) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for len(found) < k {
+		last := found[len(found)-1].Nodes
+		rootCost := 0
+		for i := 0; i < len(last)-1; i++ {
+			spur, root := last[i], last[:i+1]
+			bannedArcs := make(map[[2]int]bool)
+			for _, p := range found {
+				if len(p.Nodes) > i+1 && samePrefix(p.Nodes, root) {
+					bannedArcs[[2]int{p.Nodes[i], p.Nodes[i+1]}] = true
+				}
+			}
+			bannedNodes := make([]bool, len(adj))
+			for _, v := range root[:i] {
+				bannedNodes[v] = true
+			}
+			if spurPath := constrainedPath(adj, spur, dst, bannedNodes, bannedArcs); spurPath.Nodes != nil {
+				path := append(append([]int(nil), root[:i]...), spurPath.Nodes...)
+				if !known(path) {
+					candidates = append(candidates, indexPath{Nodes: path, Cost: rootCost + spurPath.Cost})
+				}
+			}
+			rootCost += arcWeight(adj, last[i], last[i+1])
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		best := 0
+		for i := range candidates {
+			if shorterPath(candidates[i], candidates[best]) {
+				best = i
+			}
+		}
+		found = append(found, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+	return found
+}
+
+func KShortestPaths(g *Graph, src *Node, dst *Node, k int) []weightedPath {
+	/*
+		KShortestPaths cherche les k plus courts chemins sans boucle entre deux routeurs sur le graphe
+		actuel (algorithme de Yen, voir yenPaths), indépendamment des tables de routage.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- src : le routeur source
+			- dst : le routeur destination
+			- k : le nombre de chemins cherchés
+
+		Retourne :
+			- Au plus k chemins, du plus court au plus long, nil si dst est injoignable
+	*/
+	adj := buildAdjacency(g)
+	return toWeightedPaths(g, yenPaths(adj, src.Index, dst.Index, k))
+}
+
+func toWeightedPaths(g *Graph, paths []indexPath) []weightedPath {
+	/*
+		toWeightedPaths remplace les indices des chemins par les routeurs du graphe.
+
+		Paramètres :
+			- g : le graphe dont les indices ont été numérotés par buildAdjacency
+			- paths : les chemins calculés sur les indices
+
+		Retourne :
+			- Les chemins, dans le même ordre
+	*/
+	result := make([]weightedPath, len(paths))
+	for i, p := range paths {
+		result[i] = weightedPath{Nodes: make([]*Node, len(p.Nodes)), Cost: p.Cost}
+		for j, v := range p.Nodes {
+			result[i].Nodes[j] = g.Nodes[v]
+		}
+	}
+	return result
+}
+
+func addFlowArc(network [][]flowArc, from int, to int, capacity int, cost int) {
+	/*
+		addFlowArc ajoute au réseau de flot un arc et son arc inverse (capacité nulle, coût opposé).
+
+		Paramètres :
+			- network : le réseau de flot
+			- from, to : les extrémités de l'arc
+			- capacity : la capacité de l'arc
+			- cost : le coût d'une unité de flot sur l'arc
+
+		La fonction ne retourne rien.
+	*/
+	network[from] = append(network[from], flowArc{To: to, Capacity: capacity, Initial: capacity, Cost: cost, Reverse: len(network[to])})
+	network[to] = append(network[to], flowArc{To: from, Cost: -cost, Reverse: len(network[from]) - 1})
+}
+
+func disjointPaths(adj [][]arc, src int, dst int, kind disjointKind) []indexPath {
+	/*
+		disjointPaths cherche le plus grand nombre de chemins disjoints de src à dst, et parmi eux ceux
+		dont le coût total est le plus petit (algorithme de Suurballe généralisé : flot de coût minimal
+		par plus courts chemins successifs, avec Bellman-Ford sur le réseau résiduel).
+
+		Paramètres :
+			- adj : la liste d'adjacence construite par buildAdjacency
+			- src, dst : les indices de la source et de la destination
+			- kind : linkDisjoint ou nodeDisjoint
+
+		Chaque lien a une capacité 1 dans chaque sens. Pour des chemins disjoints par les routeurs,
+		chaque routeur v est coupé en deux sommets 2v (entrée) et 2v+1 (sortie) reliés par un arc de
+		capacité 1. Comme les poids sont positifs, un flot de coût minimal n'emprunte jamais un lien
+		dans les deux sens.
+
+		Retourne :
+			- Les chemins, du moins coûteux au plus coûteux, nil si dst est injoignable
+	*/
+	in, out := func(v int) int { return v }, func(v int) int { return v }
+	size := len(adj)
+	if kind == nodeDisjoint {
+		in, out = func(v int) int { return 2 * v }, func(v int) int { return 2*v + 1 }
+		size = 2 * len(adj)
+	}
+	network := make([][]flowArc, size)
+	if kind == nodeDisjoint {
+		for v := range adj {
+			capacity := 1
+			if v == src || v == dst {
+				capacity = len(adj)
+			}
+			addFlowArc(network, in(v), out(v), capacity, 0)
+		}
+	}
+	for u := range adj {
+		for _, a := range adj[u] {
+			addFlowArc(network, out(u), in(a.To), 1, a.Weight)
+		}
+	}
+	source, sink := out(src), in(dst)
+
+	// Plus courts chemins successifs : chaque chemin augmentant ajoute une unité de flot
+	dist := make([]int, size)
+	prevNode := make([]int, size)
+	prevArc := make([]int, size)
+	for {
+		for i := range dist {
+			dist[i] = infiniteDistance
+		}
+		dist[source] = 0
+		for changed, round := true, 0; changed && round < size; round++ {
+			changed = false
+			for u := range network {
+				if dist[u] == infiniteDistance {
+					continue
+				}
+				for i, a := range network[u] {
+					if a.Capacity > 0 && dist[u]+a.Cost < dist[a.To] {
+						dist[a.To] = dist[u] + a.Cost
+						prevNode[a.To], prevArc[a.To] = u, i
+						changed = true
+					}
+				}
+			}
+		}
+		if dist[sink] == infiniteDistance {
+			break
+		}
+		for v := sink; v != source; v = prevNode[v] {
+			a := &network[prevNode[v]][prevArc[v]]
+			a.Capacity--
+			network[v][a.Reverse].Capacity++
+		}
+	}
+
+	// Décomposition du flot en chemins, en consommant le flot de chaque arc emprunté
+	nodeOf := func(vertex int) int {
+		if kind == nodeDisjoint {
+			return vertex / 2
+		}
+		return vertex
+	}
+	var paths []indexPath
+	for {
+		path := indexPath{Nodes: []int{src}}
+		for u := source; u != sink; {
+			next := -1
+			for i := range network[u] {
+				if a := &network[u][i]; a.Initial > a.Capacity {
+					a.Capacity++
+					path.Cost += a.Cost
+					next = a.To
+					break
+				}
+			}
+			if next < 0 {
+				return sortPaths(paths)
+			}
+			if nodeOf(next) != nodeOf(u) {
+				path.Nodes = append(path.Nodes, nodeOf(next))
+			}
+			u = next
+		}
+		paths = append(paths, path)
+	}
+}
+
+func sortPaths(paths []indexPath) []indexPath {
+	/*
+		sortPaths trie des chemins du plus court au plus long (voir shorterPath).
+
+		Paramètres :
+			- paths : les chemins à trier
+
+		Retourne :
+			- Les chemins triés
+	*/
+	sort.Slice(paths, func(i, j int) bool { return shorterPath(paths[i], paths[j]) })
+	return paths
+}
+
+func DisjointPaths(g *Graph, src *Node, dst *Node, kind disjointKind) []weightedPath {
+	/*
+		DisjointPaths cherche sur le graphe actuel le plus grand nombre de chemins entre deux routeurs
+		qui n'ont aucun lien (linkDisjoint) ou aucun routeur intermédiaire (nodeDisjoint) en commun,
+		avec le plus petit coût total (voir disjointPaths).
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- src : le routeur source
+			- dst : le routeur destination
+			- kind : linkDisjoint ou nodeDisjoint
+
+		D'après le théorème de Menger, la paire résiste à la panne de n'importe quel lien (ou routeur
+		intermédiaire) si et seulement s'il y a au moins deux chemins disjoints.
+
+		Retourne :
+			- Les chemins, du moins coûteux au plus coûteux, nil si dst est injoignable
+	*/
+	adj := buildAdjacency(g)
+	return toWeightedPaths(g, disjointPaths(adj, src.Index, dst.Index, kind))
+}
+
+func singlePointsOfFailure(g *Graph, path weightedPath) ([][2]*Node, []*Node) {
+	/*
+		singlePointsOfFailure cherche, sur un chemin entre deux routeurs, les liens et les routeurs
+		intermédiaires dont la panne suffit à couper la paire.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- path : un chemin de la source à la destination (tout autre chemin passe aussi par les
+			  points cherchés)
+
+		Retourne :
+			- Les liens dont la panne coupe la paire, dans l'ordre du chemin
+			- Les routeurs dont la panne coupe la paire, dans l'ordre du chemin
+	*/
+	adj := buildAdjacency(g)
+	src, dst := path.Nodes[0].Index, path.Nodes[len(path.Nodes)-1].Index
+	var links [][2]*Node
+	var routers []*Node
+	for i := 0; i+1 < len(path.Nodes); i++ {
+		u, v := path.Nodes[i], path.Nodes[i+1]
+		banned := map[[2]int]bool{{u.Index, v.Index}: true, {v.Index, u.Index}: true}
+		if constrainedPath(adj, src, dst, nil, banned).Nodes == nil {
+			links = append(links, [2]*Node{u, v})
+		}
+		if i == 0 {
+			continue
+		}
+		bannedNodes := make([]bool, len(adj))
+		bannedNodes[u.Index] = true
+		if constrainedPath(adj, src, dst, bannedNodes, nil).Nodes == nil {
+			routers = append(routers, u)
+		}
+	}
+	return links, routers
+}
+
+func printPathDiversity(g *Graph, src *Node, dst *Node, k int) bool {
+	/*
+		printPathDiversity affiche les k plus courts chemins entre deux routeurs, leurs chemins
+		disjoints par les liens et par les routeurs, et indique si la paire résiste à la panne de
+		n'importe quel lien ou routeur intermédiaire (sinon, les liens et routeurs dont la panne
+		suffit à la couper).
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- src : le routeur source
+			- dst : le routeur destination
+			- k : le nombre de plus courts chemins affichés
+
+		Retourne :
+			- true si la paire résiste à toute panne unique d'un lien ou d'un routeur intermédiaire
+	*/
+	shortest := KShortestPaths(g, src, dst, k)
+	if len(shortest) == 0 {
+		fmt.Printf("%s -> %s : destination injoignable.\n", src.Name, dst.Name)
+		return false
+	}
+	fmt.Printf("%d plus courts chemins sans boucle de %s à %s :\n", len(shortest), src.Name, dst.Name)
+	for i, p := range shortest {
+		fmt.Printf("  %2d. coût %-6d %2d sauts : %s\n", i+1, p.Cost, len(p.Nodes)-1, formatPath(p.Nodes))
+	}
+
+	survives := true
+	for _, kind := range []disjointKind{linkDisjoint, nodeDisjoint} {
+		paths := DisjointPaths(g, src, dst, kind)
+		what, failure := "les liens", "lien"
+		if kind == nodeDisjoint {
+			what, failure = "les routeurs", "routeur intermédiaire"
+		}
+		total := 0
+		for _, p := range paths {
+			total += p.Cost
+		}
+		fmt.Printf("%d chemins disjoints par %s (coût total %d) :\n", len(paths), what, total)
+		for _, p := range paths {
+			fmt.Printf("  coût %-6d %s\n", p.Cost, formatPath(p.Nodes))
+		}
+		if len(paths) >= 2 {
+			fmt.Printf("%s -> %s résiste à la panne de n'importe quel %s.\n", src.Name, dst.Name, failure)
+			continue
+		}
+		links, routers := singlePointsOfFailure(g, paths[0])
+		critical := make([]string, 0, len(links))
+		for _, link := range links {
+			critical = append(critical, link[0].Name+" - "+link[1].Name)
+		}
+		if kind == nodeDisjoint {
+			critical = critical[:0]
+			for _, router := range routers {
+				critical = append(critical, router.Name)
+			}
+		}
+		if len(critical) == 0 {
+			//Seul le lien direct relie la paire : aucun routeur intermédiaire ne peut la couper
+			fmt.Printf("%s -> %s résiste à la panne de n'importe quel %s (lien direct).\n", src.Name, dst.Name, failure)
+			continue
+		}
+		survives = false
+		fmt.Printf("%s -> %s est coupé par la panne d'un seul %s : %s.\n", src.Name, dst.Name, failure, strings.Join(critical, ", "))
+	}
+	return survives
+}
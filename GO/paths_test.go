@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func pathNodes(paths []indexPath) [][]int {
+	/*
+		pathNodes garde les routeurs des chemins, pour les comparer aux réponses attendues.
+
+		Paramètres :
+			- paths : les chemins
+
+		Retourne :
+			- Les indices des routeurs de chaque chemin, nil s'il n'y a aucun chemin
+	*/
+	var nodes [][]int
+	for _, path := range paths {
+		nodes = append(nodes, path.Nodes)
+	}
+	return nodes
+}
+
+func TestYenPaths(t *testing.T) {
+	/*
+		TestYenPaths compare les k plus courts chemins de 0 à la destination aux chemins calculés à la main.
+	*/
+	// Carré 0-1-3 / 0-2-3 avec la diagonale 1-2
+	square := [][3]int{{0, 1, 1}, {1, 3, 1}, {0, 2, 2}, {2, 3, 1}, {1, 2, 1}}
+	tests := []struct {
+		name  string
+		n     int
+		links [][3]int
+		dst   int
+		k     int
+		paths [][]int
+		costs []int
+	}{
+		{"premier chemin seulement", 4, square, 3, 1, [][]int{{0, 1, 3}}, []int{2}},
+		{"égalité départagée par le nombre de sauts", 4, square, 3, 3, [][]int{{0, 1, 3}, {0, 2, 3}, {0, 1, 2, 3}}, []int{2, 3, 3}},
+		{"moins de chemins que k", 4, square, 3, 10, [][]int{{0, 1, 3}, {0, 2, 3}, {0, 1, 2, 3}, {0, 2, 1, 3}}, []int{2, 3, 3, 4}},
+		{"chaîne", 3, [][3]int{{0, 1, 4}, {1, 2, 5}}, 2, 3, [][]int{{0, 1, 2}}, []int{9}},
+		{"destination injoignable", 3, [][3]int{{0, 1, 1}}, 2, 3, nil, nil},
+		{"k nul", 4, square, 3, 0, nil, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paths := yenPaths(undirected(test.n, test.links), 0, test.dst, test.k)
+			if got := pathNodes(paths); !reflect.DeepEqual(got, test.paths) {
+				t.Fatalf("chemins %v ; attendu %v", got, test.paths)
+			}
+			for i, path := range paths {
+				if path.Cost != test.costs[i] {
+					t.Errorf("coût du chemin %v = %d ; attendu %d", path.Nodes, path.Cost, test.costs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestYenPathsAgainstEnumeration(t *testing.T) {
+	/*
+		TestYenPathsAgainstEnumeration compare, sur des graphes aléatoires, les coûts des k plus courts
+		chemins de Yen à ceux obtenus en énumérant tous les chemins sans boucle.
+	*/
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 20; round++ {
+		n := 7
+		var links [][3]int
+		for a := 0; a < n; a++ {
+			for b := a + 1; b < n; b++ {
+				if r.Float64() < 0.4 {
+					links = append(links, [3]int{a, b, r.Intn(5) + 1})
+				}
+			}
+		}
+		adj := undirected(n, links)
+		var costs []int
+		var walk func(u int, visited []bool, cost int)
+		walk = func(u int, visited []bool, cost int) {
+			if u == n-1 {
+				costs = append(costs, cost)
+				return
+			}
+			visited[u] = true
+			for _, a := range adj[u] {
+				if !visited[a.To] {
+					walk(a.To, visited, cost+a.Weight)
+				}
+			}
+			visited[u] = false
+		}
+		walk(0, make([]bool, n), 0)
+		sort.Ints(costs)
+
+		const k = 6
+		paths := yenPaths(adj, 0, n-1, k)
+		if want := min(k, len(costs)); len(paths) != want {
+			t.Fatalf("graphe %d : %d chemins ; attendu %d", round, len(paths), want)
+		}
+		for i, path := range paths {
+			if path.Cost != costs[i] {
+				t.Errorf("graphe %d : coût du chemin %d = %d ; attendu %d", round, i, path.Cost, costs[i])
+			}
+		}
+	}
+}
+
+func TestDisjointPaths(t *testing.T) {
+	/*
+		TestDisjointPaths compare les chemins disjoints de 0 à la destination aux chemins calculés à
+		la main, dont le piège de Suurballe : le plus court chemin 0-1-2-3 n'appartient à aucune
+		paire de chemins disjoints, et le retirer ne laisserait aucun second chemin.
+	*/
+	trap := [][3]int{{0, 1, 1}, {1, 2, 1}, {2, 3, 1}, {0, 4, 2}, {4, 2, 2}, {1, 5, 2}, {5, 3, 2}}
+	diamonds := [][3]int{{0, 1, 1}, {0, 2, 1}, {1, 3, 1}, {2, 3, 1}, {3, 4, 1}, {3, 5, 1}, {4, 6, 1}, {5, 6, 1}}
+	tests := []struct {
+		name  string
+		n     int
+		links [][3]int
+		dst   int
+		kind  disjointKind
+		count int
+		total int //coût total des chemins
+	}{
+		{"piège, liens disjoints", 6, trap, 3, linkDisjoint, 2, 10},
+		{"piège, routeurs disjoints", 6, trap, 3, nodeDisjoint, 2, 10},
+		{"losanges, liens disjoints", 7, diamonds, 6, linkDisjoint, 2, 8},
+		{"losanges, routeurs disjoints", 7, diamonds, 6, nodeDisjoint, 1, 4},
+		{"lien direct et détour", 3, [][3]int{{0, 2, 5}, {0, 1, 1}, {1, 2, 1}}, 2, nodeDisjoint, 2, 7},
+		{"destination injoignable", 3, [][3]int{{0, 1, 1}}, 2, linkDisjoint, 0, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			paths := disjointPaths(undirected(test.n, test.links), 0, test.dst, test.kind)
+			if len(paths) != test.count {
+				t.Fatalf("%d chemins %v ; attendu %d", len(paths), pathNodes(paths), test.count)
+			}
+			total := 0
+			links := make(map[[2]int]bool)
+			nodes := make(map[int]bool)
+			for i, path := range paths {
+				total += path.Cost
+				if path.Nodes[0] != 0 || path.Nodes[len(path.Nodes)-1] != test.dst {
+					t.Errorf("chemin %v ne va pas de 0 à %d", path.Nodes, test.dst)
+				}
+				if i > 0 && shorterPath(path, paths[i-1]) {
+					t.Errorf("chemins mal triés : %v", pathNodes(paths))
+				}
+				for j := 1; j < len(path.Nodes); j++ {
+					a, b := path.Nodes[j-1], path.Nodes[j]
+					if a > b {
+						a, b = b, a
+					}
+					if links[[2]int{a, b}] {
+						t.Errorf("lien %d - %d emprunté par plusieurs chemins : %v", a, b, pathNodes(paths))
+					}
+					links[[2]int{a, b}] = true
+				}
+				for _, v := range path.Nodes[1 : len(path.Nodes)-1] {
+					if test.kind == nodeDisjoint && nodes[v] {
+						t.Errorf("routeur %d emprunté par plusieurs chemins : %v", v, pathNodes(paths))
+					}
+					nodes[v] = true
+				}
+			}
+			if total != test.total {
+				t.Errorf("coût total %d ; attendu %d (%v)", total, test.total, pathNodes(paths))
+			}
+		})
+	}
+}
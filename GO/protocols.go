@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//**** OUTILS COMMUNS AUX PROTOCOLES DE ROUTAGE DISTRIBUÉS ****//
+
+// Structure suivant l'exécution d'un protocole distribué : arrêt, envois en cours et convergence
+type routingProtocol struct {
+	Name     string        //nom affiché dans les messages de convergence
+	Messages string        //nom des annonces échangées (vecteurs, LSA...)
+	Delay    time.Duration //délai de transmission de chaque annonce vers un voisin
+
+	stop     chan struct{} //fermé par shutdown pour arrêter les annonces
+	stopMu   sync.Mutex
+	stopped  bool
+	sendWG   sync.WaitGroup //envois d'annonces en cours
+	tickerWG sync.WaitGroup //goroutines d'annonces périodiques
+
+	sent         atomic.Int64 //nombre d'annonces envoyées
+	inFlight     atomic.Int64 //annonces envoyées pas encore reçues
+	changes      atomic.Int64 //nombre de changements de route
+	lastChange   atomic.Int64 //date (UnixNano) du dernier changement de route
+	lastEvent    atomic.Int64 //date (UnixNano) du dernier démarrage ou changement de lien
+	eventSent    atomic.Int64 //valeurs des compteurs au moment de ce dernier événement
+	eventChanges atomic.Int64
+}
+
+func newRoutingProtocol(name string, messages string) *routingProtocol {
+	/*
+		newRoutingProtocol crée le suivi d'un protocole distribué.
+
+		Paramètres :
+			- name : le nom du protocole, affiché dans les messages de convergence
+			- messages : le nom des annonces échangées par le protocole
+
+		Retourne :
+			- Le suivi du protocole, prêt à être démarré
+	*/
+	return &routingProtocol{Name: name, Messages: messages, stop: make(chan struct{})}
+}
+
+func (p *routingProtocol) send(neighbor *Node, message Message) {
+	/*
+		send envoie une annonce du protocole sur le canal d'un voisin.
+
+		Paramètres :
+			- neighbor : le voisin destinataire
+			- message : l'annonce à envoyer
+
+		L'envoi se fait dans une goroutine pour que deux voisins qui s'envoient leurs annonces en même
+		temps ne se bloquent pas mutuellement. L'annonce n'est remise qu'après le délai Delay plus le
+		délai du lien (voir linkDelay), et l'envoi est abandonné si le protocole est arrêté ou si la
+		file d'émission du lien est pleine. En mode des, la réception est programmée après ce délai.
+
+		La fonction ne retourne rien.
+	*/
+	p.stopMu.Lock()
+	if p.stopped {
+		p.stopMu.Unlock()
+		return
+	}
+	p.sent.Add(1)
+	delay, ok := linkDelay(message.Source, neighbor, message)
+	if !ok {
+		p.stopMu.Unlock()
+		return
+	}
+	delay += p.Delay
+	if engine != nil {
+		p.stopMu.Unlock()
+		p.inFlight.Add(1)
+		engine.schedule(delay, func() {
+			p.inFlight.Add(-1)
+			if !p.isStopped() {
+				dispatchMessage(engine.graph, neighbor, message)
+			}
+		})
+		return
+	}
+	p.sendWG.Add(1)
+	p.stopMu.Unlock()
+
+	p.inFlight.Add(1)
+	go func() {
+		defer p.sendWG.Done()
+		defer p.inFlight.Add(-1)
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-p.stop:
+				return
+			}
+		}
+		select {
+		case neighbor.Channel <- message:
+		case <-p.stop:
+		}
+	}()
+}
+
+func (p *routingProtocol) every(period time.Duration, tick func(now time.Time)) {
+	/*
+		every lance une goroutine qui appelle tick à chaque période, jusqu'à l'arrêt du protocole.
+		En mode des, les appels sont des événements programmés de période en période.
+
+		Paramètres :
+			- period : l'intervalle entre deux appels
+			- tick : la fonction appelée avec la date du tick
+
+		La fonction ne retourne rien.
+	*/
+	if engine != nil {
+		var next func()
+		next = func() {
+			if p.isStopped() {
+				return
+			}
+			tick(clockNow())
+			engine.schedule(period, next)
+		}
+		engine.schedule(period, next)
+		return
+	}
+	p.tickerWG.Add(1)
+	go func() {
+		defer p.tickerWG.Done()
+		ticker := time.NewTicker(period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case now := <-ticker.C:
+				tick(now)
+			}
+		}
+	}()
+}
+
+func (p *routingProtocol) shutdown() {
+	/*
+		shutdown arrête les annonces périodiques et attend la fin des envois en cours, pour que
+		les canaux puissent ensuite être fermés sans qu'une annonce y soit encore envoyée.
+
+		La fonction ne retourne rien.
+	*/
+	p.stopMu.Lock()
+	if p.stopped {
+		p.stopMu.Unlock()
+		return
+	}
+	p.stopped = true
+	close(p.stop)
+	p.stopMu.Unlock()
+	p.tickerWG.Wait()
+	p.sendWG.Wait()
+}
+
+func (p *routingProtocol) isStopped() bool {
+	/*
+		isStopped indique si le protocole a été arrêté par shutdown.
+
+		Retourne :
+			- true si le protocole est arrêté
+	*/
+	p.stopMu.Lock()
+	defer p.stopMu.Unlock()
+	return p.stopped
+}
+
+func (p *routingProtocol) markEvent() {
+	/*
+		markEvent note le début d'une nouvelle convergence (démarrage du protocole ou changement
+		de lien), à partir duquel waitConvergence mesure le temps de convergence et compte les
+		annonces et les changements de route.
+
+		La fonction ne retourne rien.
+	*/
+	p.eventSent.Store(p.sent.Load())
+	p.eventChanges.Store(p.changes.Load())
+	p.lastEvent.Store(clockNow().UnixNano())
+	p.lastChange.Store(clockNow().UnixNano())
+}
+
+func (p *routingProtocol) routeChanged() {
+	/*
+		routeChanged compte un changement de route et retarde la détection de la convergence.
+
+		La fonction ne retourne rien.
+	*/
+	p.changes.Add(1)
+	p.lastChange.Store(clockNow().UnixNano())
+}
+
+func (p *routingProtocol) waitConvergence(quiet time.Duration, timeout time.Duration) bool {
+	/*
+		waitConvergence attend qu'aucune annonce ne soit en transit et qu'aucune route n'ait changé
+		pendant la durée quiet, puis affiche le temps de convergence, le nombre d'annonces envoyées
+		et le nombre de changements de route depuis le démarrage du protocole ou le dernier
+		changement de lien.
+
+		Paramètres :
+			- quiet : la durée sans changement au bout de laquelle le protocole est considéré convergé
+			- timeout : la durée maximale d'attente
+
+		En mode des, ces durées sont mesurées sur l'horloge virtuelle.
+
+		Retourne :
+			- true si le protocole a convergé, false si le délai a été dépassé (par exemple pendant
+			  un comptage à l'infini avec un infini trop grand)
+	*/
+	start := clockNow()
+	for {
+		last := time.Unix(0, p.lastChange.Load())
+		if p.inFlight.Load() == 0 && clockNow().Sub(last) >= quiet {
+			elapsed := last.Sub(time.Unix(0, p.lastEvent.Load()))
+			if elapsed < 0 {
+				elapsed = 0
+			}
+			fmt.Printf("\n%s : convergence en %v (%d %s envoyés, %d changements de route).\n\n", p.Name,
+				elapsed.Round(time.Microsecond), p.sent.Load()-p.eventSent.Load(), p.Messages, p.changes.Load()-p.eventChanges.Load())
+			return true
+		}
+		if clockNow().Sub(start) > timeout {
+			fmt.Printf("\n%s : pas de convergence après %v (%d changements de route).\n\n", p.Name,
+				timeout, p.changes.Load()-p.eventChanges.Load())
+			return false
+		}
+		sleepFor(10 * time.Millisecond)
+	}
+}
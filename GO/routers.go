@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+//**** PANNE ET REDÉMARRAGE D'UN ROUTEUR ****//
+
+// Routeur tombé en panne ou redémarré, contenu des messages MessageRouterDown et MessageRouterUp
+type RouterInfo struct {
+	Node *Node
+}
+
+// Lien d'un routeur en panne, mis de côté pour être rétabli à son redémarrage
+type savedLink struct {
+	Out *Edge //lien du routeur vers le voisin
+	In  *Edge //lien du voisin vers le routeur
+}
+
+// Compteurs globaux //
+var failedRouterMessages atomic.Int64 //messages arrivés sur un routeur en panne, perdus
+
+// Enregistrement des traitements des pannes de routeurs
+func init() {
+	registerHandler(MessageRouterDown, "router down", func(g *Graph, node *Node, message Message) {
+		failRouter(g, message.Payload.(RouterInfo).Node)
+		waitGroup.Done()
+	})
+	registerHandler(MessageRouterUp, "router up", func(g *Graph, node *Node, message Message) {
+		recoverRouter(g, message.Payload.(RouterInfo).Node)
+		waitGroup.Done()
+	})
+}
+
+func (node *Node) Failed() bool {
+	/*
+		Failed indique si le routeur est en panne.
+
+		Retourne :
+			- true entre failRouter et recoverRouter
+	*/
+	return node.failed.Load()
+}
+
+func failRouter(g *Graph, node *Node) {
+	/*
+		failRouter met un routeur en panne : sa goroutine processMessages est arrêtée, tous ses liens
+		sont supprimés (et gardés pour son redémarrage) et ses voisins prévenus de la perte de ces liens.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- node : le routeur qui tombe en panne
+
+		Le canal du routeur est vidé par une goroutine (voir drainMessages) : les messages qui y étaient
+		en route sont comptés comme perdus, et les demandes Hello qu'ils transportaient échouent. En mode
+		des, les messages programmés vers le routeur sont perdus à leur réception (voir dispatchMessage).
+		Le routeur perd aussi l'état de son protocole de routage, comme après une coupure de courant.
+
+		La fonction ne retourne rien.
+	*/
+	if node.Failed() {
+		return
+	}
+	node.failed.Store(true)
+	if engine == nil {
+		close(node.quit)
+		node.quit = make(chan struct{})
+		go drainMessages(node, node.quit)
+	}
+
+	var neighbors []*Node
+	for len(node.Edges) > 0 {
+		neighbor := node.Edges[0].To
+		out := removeEdge(node, neighbor)
+		in := removeEdge(neighbor, node)
+		node.savedLinks = append(node.savedLinks, savedLink{Out: out, In: in})
+		neighbors = append(neighbors, neighbor)
+	}
+	fmt.Printf("%s est en panne : %d liens perdus.\n", node.Name, len(neighbors))
+
+	switch *protocolFlag {
+	case protocolDistanceVector:
+		for _, neighbor := range neighbors {
+			distanceVectorLinkDown(neighbor, node)
+		}
+		resetDistanceVector(node)
+	case protocolLinkState:
+		for _, neighbor := range neighbors {
+			linkStateLinkDown(neighbor, node)
+		}
+		resetLinkState(node)
+	default:
+		constructAllRoutingTables(g)
+	}
+}
+
+func recoverRouter(g *Graph, node *Node) {
+	/*
+		recoverRouter redémarre un routeur en panne : sa goroutine processMessages est relancée et ses
+		liens d'origine sont rétablis, avec leurs caractéristiques (poids, délai, débit...).
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- node : le routeur qui redémarre
+
+		Un lien vers un voisin lui-même en panne n'est pas rétabli tout de suite : il est confié à ce
+		voisin, qui le rétablira à son propre redémarrage. Le routeur repart d'un protocole de routage
+		vide et apprend les routes par ses voisins, comme après l'ajout d'un lien.
+
+		La fonction ne retourne rien.
+	*/
+	if !node.Failed() {
+		return
+	}
+	node.failed.Store(false)
+	if engine == nil {
+		close(node.quit)
+		node.quit = make(chan struct{})
+		go processMessages(g, node, node.quit)
+	}
+
+	var restored []*Edge
+	links := node.savedLinks
+	node.savedLinks = nil
+	for _, link := range links {
+		neighbor := link.Out.To
+		if neighbor.Failed() {
+			neighbor.savedLinks = append(neighbor.savedLinks, savedLink{Out: link.In, In: link.Out})
+			continue
+		}
+		addEdge(node, link.Out)
+		addEdge(neighbor, link.In)
+		restored = append(restored, link.Out)
+	}
+	fmt.Printf("%s redémarre : %d liens rétablis sur %d.\n", node.Name, len(restored), len(links))
+
+	switch *protocolFlag {
+	case protocolDistanceVector:
+		for _, edge := range restored {
+			distanceVectorLinkUp(node, edge.To, edge.Weight)
+			distanceVectorLinkUp(edge.To, node, edge.Weight)
+		}
+	case protocolLinkState:
+		for _, edge := range restored {
+			linkStateLinkUp(node, edge.To, edge.Weight)
+			linkStateLinkUp(edge.To, node, edge.Weight)
+		}
+	default:
+		constructAllRoutingTables(g)
+	}
+}
+
+func drainMessages(node *Node, quit <-chan struct{}) {
+	/*
+		drainMessages remplace processMessages pendant la panne d'un routeur : elle lit son canal pour
+		que les voisins qui y envoient un message ne restent pas bloqués, et compte ces messages comme
+		perdus.
+
+		Paramètres :
+			- node : le routeur en panne
+			- quit : fermé au redémarrage du routeur
+
+		La boucle s'arrête au redémarrage du routeur ou quand son canal est fermé.
+
+		La fonction ne retourne rien.
+	*/
+	for {
+		select {
+		case <-quit:
+			return
+		case message, ok := <-node.Channel:
+			if !ok {
+				return
+			}
+			dropAtFailedRouter(node, message)
+		}
+	}
+}
+
+func dropAtFailedRouter(node *Node, message Message) {
+	/*
+		dropAtFailedRouter compte un message arrivé sur un routeur en panne. Si le message transporte une
+		demande Hello, la demande échoue aussitôt.
+
+		Paramètres :
+			- node : le routeur en panne
+			- message : le message perdu
+
+		La fonction ne retourne rien.
+	*/
+	failedRouterMessages.Add(1)
+	if request, ok := message.Payload.(*helloRequest); ok {
+		request.fail("perdu : " + node.Name + " en panne")
+	}
+}
+
+func changeRouter(graph *Graph, node *Node, messageType MessageType) {
+	/*
+		changeRouter met un routeur en panne (MessageRouterDown) ou le redémarre (MessageRouterUp),
+		puis attend que les tables de routage soient stables.
+
+		Paramètres :
+			- graph : le graphe de la simulation
+			- node : le routeur concerné
+			- messageType : MessageRouterDown ou MessageRouterUp
+
+		Comme pour changeLink, le message est traité par un autre routeur (voir controlRouter), et le
+		graphe est ensuite vérifié (voir Validate).
+
+		La fonction ne retourne rien.
+	*/
+	target := controlRouter(graph, node)
+	message := Message{Source: node, Destination: target, Type: messageType, Payload: RouterInfo{Node: node}}
+	waitGroup.Add(1)
+	if engine != nil {
+		dispatchMessage(graph, target, message)
+	} else {
+		go sendMessage(target.Channel, message)
+	}
+	waitGroup.Wait()
+	waitRoutingConvergence(graph)
+	reportViolations(graph, fmt.Sprintf("après le message %v", messageType))
+}
+
+func controlRouter(graph *Graph, except *Node) *Node {
+	/*
+		controlRouter choisit le routeur qui traite un message de contrôle (changement de lien ou panne) :
+		le dernier routeur du graphe qui n'est pas en panne, sa goroutine processMessages étant arrêtée.
+
+		Paramètres :
+			- graph : le graphe de la simulation
+			- except : un routeur à ne pas choisir (celui qui tombe en panne), ou nil
+
+		Retourne :
+			- Le routeur choisi
+	*/
+	for i := len(graph.Nodes) - 1; i >= 0; i-- {
+		node := graph.Nodes[i]
+		if node != except && !node.Failed() {
+			return node
+		}
+	}
+	return graph.Nodes[len(graph.Nodes)-1]
+}
+
+func failedRouters(g *Graph) []*Node {
+	/*
+		failedRouters retourne les routeurs en panne.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Retourne :
+			- Les routeurs en panne, dans l'ordre du graphe
+	*/
+	var failed []*Node
+	for _, node := range g.Nodes {
+		if node.Failed() {
+			failed = append(failed, node)
+		}
+	}
+	return failed
+}
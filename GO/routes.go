@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//**** ACCÈS ET AFFICHAGE DES TABLES DE ROUTAGE ****//
+
+func (node *Node) Table() map[string]*RoutingEntry {
+	/*
+		Table retourne la table de routage actuelle du nœud. Une table publiée n'est jamais modifiée
+		(elle est remplacée d'un bloc), on peut donc la parcourir sans verrou.
+
+		Retourne :
+			- La table de routage, indexée par le nom des destinations
+	*/
+	node.tableMu.RLock()
+	defer node.tableMu.RUnlock()
+	return node.RoutingTable
+}
+
+func (node *Node) Route(name string) *RoutingEntry {
+	/*
+		Route retourne l'entrée de la table de routage du nœud pour une destination.
+
+		Paramètres :
+			- name : le nom de la destination
+
+		Retourne :
+			- L'entrée de la table, nil si la destination est inconnue
+	*/
+	return node.Table()[name]
+}
+
+func (node *Node) setRoutingTable(table map[string]*RoutingEntry) {
+	/*
+		setRoutingTable remplace la table de routage du nœud.
+
+		Paramètres :
+			- table : la nouvelle table, qui ne doit plus être modifiée ensuite
+
+		La fonction ne retourne rien.
+	*/
+	node.tableMu.Lock()
+	node.RoutingTable = table
+	node.tableMu.Unlock()
+}
+
+func snapshotRoutingTables(g *Graph) map[*Node]map[string]*RoutingEntry {
+	/*
+		snapshotRoutingTables relève la table de routage actuelle de chaque nœud. Les tables publiées
+		n'étant jamais modifiées, le relevé reste valable après les recalculs suivants.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Retourne :
+			- La table de chaque nœud
+	*/
+	snapshot := make(map[*Node]map[string]*RoutingEntry, len(g.Nodes))
+	for _, node := range g.Nodes {
+		snapshot[node] = node.Table()
+	}
+	return snapshot
+}
+
+// Route modifiée entre un relevé des tables de routage et les tables actuelles
+type routeChange struct {
+	Before *RoutingEntry //entrée du relevé, nil si la destination était absente de la table
+	After  *RoutingEntry //entrée actuelle, nil si la destination a disparu de la table
+}
+
+func routeChanges(g *Graph, before map[*Node]map[string]*RoutingEntry) []routeChange {
+	/*
+		routeChanges liste les routes dont le next hop ou le coût a changé depuis un relevé.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- before : le relevé fait par snapshotRoutingTables
+
+		Retourne :
+			- Les entrées (nœud, destination) modifiées, ajoutées ou supprimées, dans l'ordre des nœuds
+			  du graphe puis des noms de destination
+	*/
+	var changes []routeChange
+	for _, node := range g.Nodes {
+		old := before[node]
+		current := node.Table()
+		names := make([]string, 0, len(current))
+		for name := range current {
+			names = append(names, name)
+		}
+		for name := range old {
+			if current[name] == nil {
+				names = append(names, name)
+			}
+		}
+		sortRouterNames(names)
+		for _, name := range names {
+			previous, entry := old[name], current[name]
+			if previous == nil || entry == nil || previous.NextHop != entry.NextHop || previous.Cost != entry.Cost {
+				changes = append(changes, routeChange{Before: previous, After: entry})
+			}
+		}
+	}
+	return changes
+}
+
+func describeRoute(entry *RoutingEntry) string {
+	/*
+		describeRoute décrit le coût et le next hop d'une entrée, sans sa source ni sa destination.
+
+		Paramètres :
+			- entry : l'entrée, nil si la destination est absente de la table
+
+		Retourne :
+			- "coût 42 via R5" ("via R5 ou R7" s'il y a plusieurs chemins de même coût), "injoignable"
+			  ou "absente"
+	*/
+	switch {
+	case entry == nil:
+		return "absente"
+	case !entry.Reachable():
+		return "injoignable"
+	default:
+		return fmt.Sprintf("coût %d via %s", entry.Cost, formatNextHops(entry))
+	}
+}
+
+func (entry *RoutingEntry) Reachable() bool {
+	/*
+		Reachable indique si la destination de l'entrée est joignable.
+
+		Retourne :
+			- true si un chemin existe vers la destination, false sinon
+	*/
+	return entry != nil && entry.Cost != infiniteDistance
+}
+
+func (entry *RoutingEntry) Path() []*Node {
+	/*
+		Path reconstruit le chemin complet de l'entrée, de la source à la destination incluses.
+
+		Chaque entrée ne garde que l'entrée du nœud précédent sur le chemin (Parent) : le chemin
+		est reconstruit en remontant ces entrées, ce qui évite de stocker N chemins par table.
+
+		Retourne :
+			- La liste des nœuds du chemin, nil si la destination est injoignable ou si le protocole
+			  ne connaît pas le chemin complet (vecteurs de distances)
+	*/
+	if !entry.Reachable() {
+		return nil
+	}
+	path := make([]*Node, entry.HopCount+1)
+	e := entry
+	for i := entry.HopCount; i >= 0; i-- {
+		if e == nil {
+			return nil
+		}
+		path[i] = e.Destination
+		e = e.Parent
+	}
+	return path
+}
+
+func (entry *RoutingEntry) String() string {
+	/*
+		String décrit l'entrée sous la forme "R3 -> R17 coût 42 via R5" (ou "via R5 ou R7" s'il y a
+		plusieurs chemins de même coût).
+
+		Retourne :
+			- La description de l'entrée
+	*/
+	switch {
+	case !entry.Reachable():
+		return fmt.Sprintf("%s -> %s injoignable", entry.Source.Name, entry.Destination.Name)
+	case entry.HopCount == 0:
+		return fmt.Sprintf("%s -> %s coût 0 (local)", entry.Source.Name, entry.Destination.Name)
+	default:
+		return fmt.Sprintf("%s -> %s coût %d via %s", entry.Source.Name, entry.Destination.Name, entry.Cost, formatNextHops(entry))
+	}
+}
+
+func formatPath(path []*Node) string {
+	/*
+		formatPath écrit un chemin sous la forme "R3 R5 R17".
+
+		Paramètres :
+			- path : la liste ordonnée des nœuds du chemin
+
+		Retourne :
+			- Les noms des nœuds séparés par des espaces
+	*/
+	names := make([]string, len(path))
+	for i, node := range path {
+		names[i] = node.Name
+	}
+	return strings.Join(names, " ")
+}
+
+func printRoute(nodeSrc *Node, nodeDst *Node) {
+	/*
+		printRoute affiche la route de nodeSrc vers nodeDst telle que la table de nodeSrc la connaît :
+		coût, next hop, nombre de sauts, chemin complet et date de dernière modification.
+
+		Paramètres :
+			- nodeSrc : le routeur dont on lit la table de routage
+			- nodeDst : la destination
+
+		La fonction ne retourne rien.
+	*/
+	entry := nodeSrc.Route(nodeDst.Name)
+	if !entry.Reachable() {
+		fmt.Printf("%s -> %s : destination injoignable.\n", nodeSrc.Name, nodeDst.Name)
+		return
+	}
+	fmt.Printf("%s (%d sauts, modifiée à %s)\n", entry, entry.HopCount, entry.Updated.Format("15:04:05.000"))
+	if path := entry.Path(); path != nil {
+		fmt.Printf("Chemin : %s\n", formatPath(path))
+	} else {
+		fmt.Println("Chemin complet inconnu avec ce protocole (seul le next hop est connu).")
+	}
+}
+
+func sortRouterNames(names []string) {
+	/*
+		sortRouterNames trie des noms de routeurs dans l'ordre naturel (R2 avant R10).
+
+		Paramètres :
+			- names : les noms, triés sur place
+
+		La fonction ne retourne rien.
+	*/
+	sort.Slice(names, func(i, j int) bool {
+		// R2 avant R10 : on compare d'abord la longueur des noms
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) < len(names[j])
+		}
+		return names[i] < names[j]
+	})
+}
+
+func printRoutingTable(node *Node) {
+	/*
+		printRoutingTable affiche la table de routage d'un routeur, triée par nom de destination.
+
+		Paramètres :
+			- node : le routeur dont on affiche la table
+
+		La fonction ne retourne rien.
+	*/
+	table := node.Table()
+	names := make([]string, 0, len(table))
+	for name := range table {
+		if name != node.Name {
+			names = append(names, name)
+		}
+	}
+	sortRouterNames(names)
+	fmt.Printf("\nTable de routage de %s :\n", node.Name)
+	for _, name := range names {
+		entry := table[name]
+		if entry.Reachable() {
+			fmt.Printf("  %s (%d sauts)\n", entry, entry.HopCount)
+		} else {
+			fmt.Printf("  %s\n", entry)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//**** SCÉNARIOS : ÉVÉNEMENTS PROGRAMMÉS SUR LA TOPOLOGIE ET LE TRAFIC ****//
+
+// Actions possibles dans un fichier de scénario
+const (
+	actionHelloAll    = "hello-all"    //un Hello entre chaque paire de routeurs
+	actionHelloRandom = "hello-random" //un Hello de chaque routeur vers une destination aléatoire
+	actionHello       = "hello"        //un Hello entre deux routeurs
+	actionPing        = "ping"         //plusieurs Hello successifs entre deux routeurs
+	actionCut         = "cut"          //suppression d'un lien
+	actionRestore     = "restore"      //ajout (ou rétablissement) d'un lien
+	actionCost        = "cost"         //changement du poids d'un lien
+	actionFail        = "fail"         //panne d'un routeur
+	actionRecover     = "recover"      //redémarrage d'un routeur en panne
+	actionClose       = "close"        //fermeture des canaux, fin du scénario
+)
+
+// Événement d'un scénario, exécuté At après le début du scénario
+type scenarioEvent struct {
+	At     time.Duration
+	Action string
+	Nodes  []*Node //routeurs concernés (source et destination, extrémités du lien ou routeur en panne)
+	Count  int     //nombre de Hello d'un ping
+	Weight int     //poids du lien ajouté ou nouveau poids du lien (restore, cost)
+	Line   int     //ligne du fichier, pour les messages d'erreur
+}
+
+// Bilan d'une phase : de l'exécution d'un événement jusqu'à l'événement suivant
+type scenarioPhase struct {
+	Event        scenarioEvent
+	Late         time.Duration //retard de l'événement sur l'heure prévue (événement précédent trop long)
+	Sent         int           //Hello envoyés
+	Lost         int           //Hello sans Hello Ack
+	Expired      int64         //messages expirés en transit pendant la phase
+	Latency      time.Duration //latence de bout en bout moyenne des Hello acquittés
+	Retries      int           //retransmissions de Hello
+	RouteChanges int           //routes modifiées pendant la phase
+	Unreachable  int           //routes devenues injoignables pendant la phase
+	Failure      string        //cause de l'échec de l'événement lui-même, vide s'il a réussi
+}
+
+func loadScenario(path string, g *Graph) ([]scenarioEvent, error) {
+	/*
+		loadScenario lit un fichier de scénario. Chaque ligne décrit un événement :
+
+			<temps> <action> [arguments]
+
+		où le temps est une durée depuis le début du scénario (0s, 500ms, 2s...), dans l'ordre du
+		fichier, et l'action est l'une de :
+			- hello-all : un Hello entre chaque paire de routeurs
+			- hello-random : un Hello de chaque routeur vers une destination aléatoire
+			- hello A B : un Hello de A vers B
+			- ping A B [n] : n Hello successifs de A vers B (3 par défaut)
+			- cut A B : suppression du lien A - B (A-B est aussi accepté)
+			- restore A B [w] : ajout du lien A - B de poids w (par défaut, le poids qu'il avait avant
+			  d'être coupé, 1 pour un nouveau lien)
+			- cost A B w : changement du poids du lien A - B
+			- fail R : panne du routeur R (tous ses liens sont coupés)
+			- recover R : redémarrage du routeur R avec ses liens d'origine
+			- close : fermeture des canaux, qui termine le scénario
+		Les lignes vides et le texte après # sont ignorés. Les routeurs sont donnés par leur nom ou
+		leur numéro.
+
+		Paramètres :
+			- path : le chemin du fichier
+			- g : le graphe sur lequel le scénario sera joué, pour vérifier les noms des routeurs
+
+		Retourne :
+			- Les événements, dans l'ordre du fichier
+			- Une erreur qui regroupe tous les problèmes trouvés, nil si le scénario est valide
+	*/
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []scenarioEvent
+	var errs []error
+	weights := make(map[[2]*Node]int) //poids des liens coupés ou modifiés, pour restore sans poids
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		event, err := parseScenarioEvent(g, fields)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("ligne %d : %w", line, err))
+			continue
+		}
+		event.Line = line
+		scenarioLinkWeight(g, weights, &event)
+		if n := len(events); n > 0 {
+			if event.At < events[n-1].At {
+				errs = append(errs, fmt.Errorf("ligne %d : %v est avant l'événement précédent (%v)", line, event.At, events[n-1].At))
+			}
+			if events[n-1].Action == actionClose {
+				errs = append(errs, fmt.Errorf("ligne %d : événement après close (ligne %d)", line, events[n-1].Line))
+			}
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 && len(errs) == 0 {
+		errs = append(errs, errors.New("aucun événement"))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s : scénario invalide :\n%w", path, errors.Join(errs...))
+	}
+	return events, nil
+}
+
+func parseScenarioEvent(g *Graph, fields []string) (scenarioEvent, error) {
+	/*
+		parseScenarioEvent lit un événement à partir des mots d'une ligne de scénario.
+
+		Paramètres :
+			- g : le graphe du scénario
+			- fields : les mots de la ligne, le premier étant le temps
+
+		Retourne :
+			- L'événement (sans numéro de ligne)
+			- Une erreur si le temps, l'action ou les arguments sont invalides
+	*/
+	at, err := time.ParseDuration(fields[0])
+	if err != nil || at < 0 {
+		return scenarioEvent{}, fmt.Errorf("temps invalide : %s", fields[0])
+	}
+	if len(fields) < 2 {
+		return scenarioEvent{}, errors.New("action manquante")
+	}
+	event := scenarioEvent{At: at, Action: fields[1]}
+	args := fields[2:]
+
+	switch event.Action {
+	case actionHelloAll, actionHelloRandom, actionClose:
+		if len(args) > 0 {
+			return event, fmt.Errorf("%s n'a pas d'argument", event.Action)
+		}
+		return event, nil
+	case actionHello, actionCut:
+	case actionRestore, actionCost:
+		if len(args) == 3 || (len(args) == 2 && strings.Contains(args[0], "-")) {
+			last := args[len(args)-1]
+			weight, err := strconv.Atoi(last)
+			if err != nil || weight < 1 {
+				return event, fmt.Errorf("poids invalide : %s", last)
+			}
+			event.Weight = weight
+			args = args[:len(args)-1]
+		} else if event.Action == actionCost {
+			return event, errors.New("cost attend deux routeurs et un poids")
+		}
+	case actionFail, actionRecover:
+		if len(args) != 1 {
+			return event, fmt.Errorf("%s attend un routeur", event.Action)
+		}
+		node, err := lookupRouter(g, args[0])
+		if err != nil {
+			return event, err
+		}
+		event.Nodes = []*Node{node}
+		return event, nil
+	case actionPing:
+		event.Count = 3
+		if len(args) == 3 {
+			count, err := strconv.Atoi(args[2])
+			if err != nil || count < 1 {
+				return event, fmt.Errorf("nombre de Hello invalide : %s", args[2])
+			}
+			event.Count = count
+			args = args[:2]
+		}
+	default:
+		return event, fmt.Errorf("action inconnue : %s", event.Action)
+	}
+
+	if len(args) == 1 && (event.Action == actionCut || event.Action == actionRestore || event.Action == actionCost) {
+		if a, b, ok := strings.Cut(args[0], "-"); ok {
+			args = []string{a, b}
+		}
+	}
+	if len(args) != 2 {
+		return event, fmt.Errorf("%s attend deux routeurs", event.Action)
+	}
+	for _, name := range args {
+		node, err := lookupRouter(g, name)
+		if err != nil {
+			return event, err
+		}
+		event.Nodes = append(event.Nodes, node)
+	}
+	if event.Nodes[0] == event.Nodes[1] {
+		return event, fmt.Errorf("%s : les deux routeurs sont identiques", event.Action)
+	}
+	return event, nil
+}
+
+func scenarioLinkWeight(g *Graph, weights map[[2]*Node]int, event *scenarioEvent) {
+	/*
+		scenarioLinkWeight suit, pendant la lecture d'un scénario, le poids de chaque lien coupé ou
+		modifié, pour qu'un lien rétabli sans poids (restore A B) retrouve celui qu'il avait.
+
+		Paramètres :
+			- g : le graphe du scénario, qui donne le poids initial des liens
+			- weights : les poids connus, indexés par les deux extrémités du lien dans les deux sens
+			- event : l'événement lu, dont le poids est complété pour un restore sans poids
+
+		La fonction ne retourne rien.
+	*/
+	if len(event.Nodes) != 2 {
+		return
+	}
+	a, b := event.Nodes[0], event.Nodes[1]
+	switch event.Action {
+	case actionCut:
+		if _, known := weights[[2]*Node{a, b}]; !known {
+			if weight := linkWeight(a, b); weight > 0 {
+				weights[[2]*Node{a, b}], weights[[2]*Node{b, a}] = weight, weight
+			}
+		}
+	case actionCost:
+		weights[[2]*Node{a, b}], weights[[2]*Node{b, a}] = event.Weight, event.Weight
+	case actionRestore:
+		if event.Weight == 0 {
+			event.Weight = weights[[2]*Node{a, b}]
+		}
+		if event.Weight == 0 {
+			event.Weight = 1
+		}
+		weights[[2]*Node{a, b}], weights[[2]*Node{b, a}] = event.Weight, event.Weight
+	}
+}
+
+func (event scenarioEvent) String() string {
+	/*
+		String décrit l'action d'un événement, telle qu'elle est écrite dans le fichier.
+
+		Retourne :
+			- L'action et ses arguments
+	*/
+	parts := []string{event.Action}
+	for _, node := range event.Nodes {
+		parts = append(parts, node.Name)
+	}
+	if event.Action == actionPing {
+		parts = append(parts, strconv.Itoa(event.Count))
+	}
+	if event.Action == actionRestore || event.Action == actionCost {
+		parts = append(parts, strconv.Itoa(event.Weight))
+	}
+	return strings.Join(parts, " ")
+}
+
+func runScenario(g *Graph, events []scenarioEvent) []scenarioPhase {
+	/*
+		runScenario joue les événements d'un scénario à l'heure prévue. Chaque événement est exécuté
+		jusqu'au bout (Hello Ack reçus ou délai dépassé, convergence après un changement de lien)
+		avant de passer au suivant : un événement qui dure plus longtemps que l'écart avec le suivant
+		retarde donc celui-ci, et le retard est indiqué dans le rapport. En mode des, les temps du
+		scénario sont ceux de l'horloge virtuelle : une heure simulée ne dure que le temps de calcul.
+
+		Paramètres :
+			- g : le graphe de la simulation, dont les protocoles sont déjà démarrés
+			- events : les événements, dans l'ordre
+
+		Les canaux sont fermés par l'événement close ou, à défaut, par l'appelant (voir stopNetwork).
+
+		Retourne :
+			- Le bilan de chaque phase
+	*/
+	phases := make([]scenarioPhase, len(events))
+	start := clockNow()
+	closed := false
+	for i, event := range events {
+		if wait := start.Add(event.At).Sub(clockNow()); wait > 0 {
+			sleepFor(wait)
+		}
+		phase := &phases[i]
+		phase.Event = event
+		phase.Late = clockNow().Sub(start) - event.At
+		fmt.Printf("\n[scénario %v] %s\n", event.At, event)
+
+		before := snapshotRoutingTables(g)
+		expired := expiredMessages.Load()
+		closed = runScenarioEvent(g, phase)
+
+		// La phase se termine au début de l'événement suivant
+		if i+1 < len(events) && !closed {
+			if wait := start.Add(events[i+1].At).Sub(clockNow()); wait > 0 {
+				sleepFor(wait)
+			}
+		}
+		diff := diffRoutingTables(g, before, fmt.Sprintf("[scénario %v] %s", event.At, event))
+		phase.RouteChanges = len(diff.Changes)
+		phase.Unreachable = len(diff.Unreachable)
+		if phase.RouteChanges > 0 {
+			dumpRoutingDiff(diff)
+		}
+		phase.Expired = expiredMessages.Load() - expired
+	}
+	return phases
+}
+
+func runScenarioEvent(g *Graph, phase *scenarioPhase) bool {
+	/*
+		runScenarioEvent exécute un événement et remplit le bilan de sa phase.
+
+		Paramètres :
+			- g : le graphe de la simulation
+			- phase : la phase de l'événement, dont Event est renseigné
+
+		Retourne :
+			- true si l'événement a fermé les canaux
+	*/
+	event := phase.Event
+	var requests []*helloRequest
+
+	switch event.Action {
+	case actionHelloAll:
+		for _, nodeSrc := range g.Nodes {
+			for _, nodeDst := range g.Nodes {
+				if nodeSrc != nodeDst && !nodeSrc.Failed() {
+					requests = append(requests, newHelloRequest(nodeSrc, nodeDst))
+				}
+			}
+		}
+	case actionHelloRandom:
+		requests = randomHelloRequests(g)
+	case actionHello:
+		requests = []*helloRequest{newHelloRequest(event.Nodes[0], event.Nodes[1])}
+	case actionPing:
+		// Les Hello d'un ping sont envoyés l'un après l'autre
+		var pings []*helloRequest
+		for n := 0; n < event.Count; n++ {
+			request := newHelloRequest(event.Nodes[0], event.Nodes[1])
+			phase.Sent++
+			phase.Lost += len(helloRound([]*helloRequest{request}, *helloTimeout))
+			pings = append(pings, request)
+		}
+		_, phase.Latency, _ = helloLatency(pings)
+		phase.Retries = helloRetransmissions(pings)
+	case actionCut:
+		if !edgeExists(event.Nodes[0], event.Nodes[1]) {
+			phase.Failure = fmt.Sprintf("pas de lien entre %s et %s", event.Nodes[0].Name, event.Nodes[1].Name)
+			break
+		}
+		changeLink(g, LinkInfo{NodeA: event.Nodes[0], NodeB: event.Nodes[1]}, MessageLinkDown)
+	case actionRestore:
+		if refusal := linkRefusal(event.Nodes[0], event.Nodes[1]); refusal != "" {
+			phase.Failure = refusal
+			break
+		}
+		changeLink(g, LinkInfo{NodeA: event.Nodes[0], NodeB: event.Nodes[1], Weight: event.Weight}, MessageLinkUp)
+	case actionCost:
+		if !edgeExists(event.Nodes[0], event.Nodes[1]) {
+			phase.Failure = fmt.Sprintf("pas de lien entre %s et %s", event.Nodes[0].Name, event.Nodes[1].Name)
+			break
+		}
+		changeLink(g, LinkInfo{NodeA: event.Nodes[0], NodeB: event.Nodes[1], Weight: event.Weight}, MessageLinkCost)
+	case actionFail:
+		if event.Nodes[0].Failed() {
+			phase.Failure = fmt.Sprintf("%s est déjà en panne", event.Nodes[0].Name)
+			break
+		}
+		changeRouter(g, event.Nodes[0], MessageRouterDown)
+	case actionRecover:
+		if !event.Nodes[0].Failed() {
+			phase.Failure = fmt.Sprintf("%s n'est pas en panne", event.Nodes[0].Name)
+			break
+		}
+		changeRouter(g, event.Nodes[0], MessageRouterUp)
+	case actionClose:
+		stopNetwork(g)
+		return true
+	}
+
+	if requests != nil {
+		phase.Sent = len(requests)
+		phase.Lost = len(helloRound(requests, *helloTimeout))
+		_, phase.Latency, _ = helloLatency(requests)
+		phase.Retries = helloRetransmissions(requests)
+	}
+	if phase.Failure != "" {
+		fmt.Printf("Événement ligne %d non exécuté : %s\n", event.Line, phase.Failure)
+	}
+	return false
+}
+
+func printScenarioReport(phases []scenarioPhase) {
+	/*
+		printScenarioReport affiche le bilan de chaque phase d'un scénario (dont la latence de bout en
+		bout moyenne des Hello acquittés et les retransmissions) et le total.
+
+		Paramètres :
+			- phases : les bilans retournés par runScenario
+
+		La fonction ne retourne rien.
+	*/
+	fmt.Printf("\nRapport du scénario :\n")
+	fmt.Printf("%-6s %-9s %-22s %6s %6s %6s %7s %10s %8s %8s %8s\n", "Phase", "Début", "Action", "Hello", "Reçus", "Perdus", "Retrans", "Latence", "Expirés", "Routes", "Injoign.")
+	var sent, lost, retries, changes, unreachable int
+	var expired int64
+	for i, phase := range phases {
+		action := phase.Event.String()
+		if phase.Failure != "" {
+			action += " (échec)"
+		}
+		latency := "-"
+		if phase.Latency > 0 {
+			latency = phase.Latency.Round(time.Microsecond).String()
+		}
+		fmt.Printf("%-6d %-9v %-22s %6d %6d %6d %7d %10s %8d %8d %8d", i+1, phase.Event.At, action, phase.Sent, phase.Sent-phase.Lost, phase.Lost,
+			phase.Retries, latency, phase.Expired, phase.RouteChanges, phase.Unreachable)
+		if phase.Late > 10*time.Millisecond {
+			fmt.Printf("  retard %v", phase.Late.Round(time.Millisecond))
+		}
+		fmt.Println()
+		sent += phase.Sent
+		lost += phase.Lost
+		retries += phase.Retries
+		expired += phase.Expired
+		changes += phase.RouteChanges
+		unreachable += phase.Unreachable
+	}
+	fmt.Printf("%-6s %-9s %-22s %6d %6d %6d %7d %10s %8d %8d %8d\n", "Total", "", "", sent, sent-lost, lost, retries, "", expired, changes, unreachable)
+}
+
+func scenarioSucceeded(phases []scenarioPhase) bool {
+	/*
+		scenarioSucceeded indique si tous les événements ont été exécutés et tous les Hello acquittés.
+
+		Paramètres :
+			- phases : les bilans retournés par runScenario
+
+		Retourne :
+			- true si aucun Hello n'a été perdu et aucun événement n'a échoué
+	*/
+	for _, phase := range phases {
+		if phase.Lost > 0 || phase.Failure != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScenario(t *testing.T, content string) string {
+	/*
+		writeScenario écrit un fichier de scénario dans un dossier temporaire du test.
+
+		Paramètres :
+			- t : le test
+			- content : le contenu du fichier
+
+		Retourne :
+			- Le chemin du fichier
+	*/
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadScenario(t *testing.T) {
+	/*
+		TestLoadScenario lit un scénario valide sur la topologie du laboratoire : temps, actions,
+		routeurs donnés par nom ou numéro, et poids d'un lien rétabli sans poids.
+	*/
+	graph, _, err := loadTopology("topologies/lab.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeScenario(t, `# coupure et rétablissement du lien lent
+0s     hello-all
+500ms  ping R1 6 2
+1s     cut R3-R4      # poids 10
+1500ms cost 1 2 7
+2s     restore 3 4
+2s     fail R5
+3s     recover R5
+4s     close
+`)
+	events, err := loadScenario(path, &graph)
+	if err != nil {
+		t.Fatalf("scénario valide refusé : %v", err)
+	}
+	want := []struct {
+		at     time.Duration
+		action string
+		line   int
+	}{
+		{0, "hello-all", 2},
+		{500 * time.Millisecond, "ping R1 R6 2", 3},
+		{time.Second, "cut R3 R4", 4},
+		{1500 * time.Millisecond, "cost R1 R2 7", 5},
+		{2 * time.Second, "restore R3 R4 10", 6},
+		{2 * time.Second, "fail R5", 7},
+		{3 * time.Second, "recover R5", 8},
+		{4 * time.Second, "close", 9},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("%d événements lus ; attendu %d", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.At != want[i].at || event.String() != want[i].action || event.Line != want[i].line {
+			t.Errorf("événement %d = %v %q ligne %d ; attendu %v %q ligne %d", i, event.At, event.String(), event.Line,
+				want[i].at, want[i].action, want[i].line)
+		}
+	}
+}
+
+func TestLoadScenarioErrors(t *testing.T) {
+	/*
+		TestLoadScenarioErrors vérifie que les scénarios invalides sont refusés avec la ligne fautive.
+	*/
+	graph, _, err := loadTopology("topologies/lab.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"temps invalide", "bientôt hello-all\n", "ligne 1 : temps invalide"},
+		{"temps négatif", "-1s hello-all\n", "ligne 1 : temps invalide"},
+		{"action manquante", "0s\n", "ligne 1 : action manquante"},
+		{"action inconnue", "0s reboot R1\n", "ligne 1 : action inconnue"},
+		{"argument en trop", "0s hello-all R1\n", "ligne 1 : hello-all n'a pas d'argument"},
+		{"cost sans poids", "0s cost R1 R2\n", "ligne 1 : cost attend deux routeurs et un poids"},
+		{"poids invalide", "0s restore R1 R2 0\n", "ligne 1 : poids invalide"},
+		{"ping invalide", "0s ping R1 R2 zéro\n", "ligne 1 : nombre de Hello invalide"},
+		{"routeur inconnu", "0s hello R1 R9\n", "ligne 1 : routeur inconnu : R9"},
+		{"routeurs identiques", "0s cut R2 R2\n", "ligne 1 : cut : les deux routeurs sont identiques"},
+		{"fail sans routeur", "0s fail\n", "ligne 1 : fail attend un routeur"},
+		{"ordre des temps", "2s hello-all\n1s hello-all\n", "ligne 2 : 1s est avant l'événement précédent"},
+		{"après close", "1s close\n2s hello-all\n", "ligne 2 : événement après close (ligne 1)"},
+		{"vide", "# rien\n\n", "aucun événement"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadScenario(writeScenario(t, test.content), &graph)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("erreur = %v ; attendu une erreur contenant %q", err, test.want)
+			}
+		})
+	}
+}
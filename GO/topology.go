@@ -0,0 +1,580 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//**** CHARGEMENT D'UNE TOPOLOGIE DEPUIS UN FICHIER ****//
+
+// Structure décrivant une topologie telle qu'elle est écrite dans un fichier JSON ou YAML
+type TopologyFile struct {
+	MaxInterfaces int            `json:"max_interfaces"` //optionnel, par défaut le degré maximal de la topologie
+	Seed          int64          `json:"seed"`           //optionnel, graine aléatoire du trafic si -seed n'est pas donné
+	Routers       []string       `json:"routers"`
+	Links         []TopologyLink `json:"links"`
+}
+
+// Structure décrivant un lien d'une topologie chargée depuis un fichier
+type TopologyLink struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Weight    int      `json:"weight"`              //optionnel, 1 par défaut
+	Delay     string   `json:"delay,omitempty"`     //optionnel, délai de propagation ("5ms"), -latency par défaut
+	Bandwidth *int64   `json:"bandwidth,omitempty"` //optionnel, débit en bits par seconde (0 : illimité), -bandwidth par défaut
+	Queue     *int     `json:"queue,omitempty"`     //optionnel, taille de la file d'émission (0 : illimitée), -queue par défaut
+	Loss      *float64 `json:"loss,omitempty"`      //optionnel, probabilité de perte d'un message, -loss par défaut
+	Corrupt   *float64 `json:"corrupt,omitempty"`   //optionnel, probabilité d'altération d'un message, -corrupt par défaut
+	Duplicate *float64 `json:"duplicate,omitempty"` //optionnel, probabilité de duplication d'un message, -duplicate par défaut
+}
+
+// Les caractéristiques optionnelles d'un lien sont des pointeurs : nil quand le champ est absent du
+// fichier (valeur de l'option), pour qu'une valeur 0 écrite explicitement remplace l'option.
+
+func loadTopology(path string) (Graph, TopologyFile, error) {
+	/*
+		loadTopology lit une topologie (routeurs, liens et poids) depuis un fichier JSON ou YAML,
+		la valide puis construit le graphe correspondant.
+
+		Paramètres :
+			- path : le chemin du fichier, le format est choisi selon l'extension (.json, .yaml ou .yml)
+
+		Les nœuds sont créés dans l'ordre du fichier avec leur canal de messages, chaque lien donne
+		lieu à deux Edges (un dans chaque sens) de même poids.
+
+		Retourne :
+			- Le graphe construit
+			- La topologie lue, pour ses options (graine aléatoire, ...)
+			- Une erreur si le fichier est illisible ou si la topologie n'est pas valide
+	*/
+	var topo TopologyFile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Graph{}, topo, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &topo)
+	case ".yaml", ".yml":
+		topo, err = parseTopologyYAML(string(data))
+	default:
+		return Graph{}, topo, fmt.Errorf("%s : extension inconnue, attendu .json, .yaml ou .yml", path)
+	}
+	if err != nil {
+		return Graph{}, topo, fmt.Errorf("%s : %w", path, err)
+	}
+
+	if err := validateTopology(topo); err != nil {
+		return Graph{}, topo, fmt.Errorf("%s : topologie invalide :\n%w", path, err)
+	}
+	return buildTopology(topo), topo, nil
+}
+
+func validateTopology(topo TopologyFile) error {
+	/*
+		validateTopology vérifie qu'une topologie peut être transformée en graphe.
+
+		Paramètres :
+			- topo : la topologie lue depuis le fichier
+
+		Sont refusés : une topologie de moins de deux routeurs, les noms de routeurs vides ou en double,
+		les liens vers des routeurs inconnus, les liens d'un routeur vers lui-même (arête boucle), les
+		liens en double (dans un sens ou dans l'autre), les poids négatifs (un poids absent ou nul vaut 1),
+		les délais invalides ou négatifs, les débits et tailles de file négatifs,
+		les routeurs qui dépassent max_interfaces et les topologies non connexes.
+
+		Retourne :
+			- Une erreur qui regroupe tous les problèmes trouvés, nil si la topologie est valide
+	*/
+	var errs []error
+
+	if len(topo.Routers) < 2 {
+		return errors.New("il faut au moins deux routeurs")
+	}
+
+	index := make(map[string]int, len(topo.Routers))
+	for i, name := range topo.Routers {
+		if name == "" {
+			errs = append(errs, fmt.Errorf("routeur n°%d sans nom", i+1))
+			continue
+		}
+		if _, exists := index[name]; exists {
+			errs = append(errs, fmt.Errorf("routeur %s défini plusieurs fois", name))
+			continue
+		}
+		index[name] = i
+	}
+
+	// Union-find pour détecter les composantes non connexes
+	parent := make([]int, len(topo.Routers))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	degree := make(map[string]int)
+	seen := make(map[[2]string]bool)
+	for i, link := range topo.Links {
+		a, okA := index[link.From]
+		b, okB := index[link.To]
+		if !okA {
+			errs = append(errs, fmt.Errorf("lien n°%d : routeur inconnu %q", i+1, link.From))
+		}
+		if !okB {
+			errs = append(errs, fmt.Errorf("lien n°%d : routeur inconnu %q", i+1, link.To))
+		}
+		if !okA || !okB {
+			continue
+		}
+		if a == b {
+			errs = append(errs, fmt.Errorf("lien n°%d : arête boucle sur %s", i+1, link.From))
+			continue
+		}
+		if link.Weight < 0 {
+			errs = append(errs, fmt.Errorf("lien n°%d : poids %d négatif entre %s et %s", i+1, link.Weight, link.From, link.To))
+			continue
+		}
+		if link.Delay != "" {
+			if delay, err := time.ParseDuration(link.Delay); err != nil || delay < 0 {
+				errs = append(errs, fmt.Errorf("lien n°%d : délai %q invalide entre %s et %s", i+1, link.Delay, link.From, link.To))
+				continue
+			}
+		}
+		if link.Bandwidth != nil && *link.Bandwidth < 0 || link.Queue != nil && *link.Queue < 0 {
+			errs = append(errs, fmt.Errorf("lien n°%d : débit ou taille de file négatif entre %s et %s", i+1, link.From, link.To))
+			continue
+		}
+		if !isOptionalProbability(link.Loss) || !isOptionalProbability(link.Corrupt) || !isOptionalProbability(link.Duplicate) {
+			errs = append(errs, fmt.Errorf("lien n°%d : taux d'erreur hors de [0, 1] entre %s et %s", i+1, link.From, link.To))
+			continue
+		}
+		key := [2]string{link.From, link.To}
+		if link.From > link.To {
+			key = [2]string{link.To, link.From}
+		}
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("lien n°%d : lien %s - %s en double", i+1, link.From, link.To))
+			continue
+		}
+		seen[key] = true
+		degree[link.From]++
+		degree[link.To]++
+		parent[find(a)] = find(b)
+	}
+
+	if topo.MaxInterfaces < 0 {
+		errs = append(errs, fmt.Errorf("max_interfaces %d invalide", topo.MaxInterfaces))
+	} else if topo.MaxInterfaces > 0 {
+		for _, name := range topo.Routers {
+			if degree[name] > topo.MaxInterfaces {
+				errs = append(errs, fmt.Errorf("%s a %d liens, plus que max_interfaces = %d", name, degree[name], topo.MaxInterfaces))
+			}
+		}
+	}
+
+	// On ne teste la connexité que si les routeurs et les liens sont cohérents
+	if len(errs) == 0 {
+		components := make(map[int][]string)
+		for _, name := range topo.Routers {
+			root := find(index[name])
+			components[root] = append(components[root], name)
+		}
+		if len(components) > 1 {
+			var parts []string
+			for _, name := range topo.Routers {
+				if members, ok := components[find(index[name])]; ok {
+					parts = append(parts, "{"+strings.Join(members, ", ")+"}")
+					delete(components, find(index[name]))
+				}
+			}
+			errs = append(errs, fmt.Errorf("topologie non connexe, %d composantes : %s", len(parts), strings.Join(parts, " ")))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func buildTopology(topo TopologyFile) Graph {
+	/*
+		buildTopology construit le graphe décrit par une topologie déjà validée.
+
+		Paramètres :
+			- topo : la topologie validée par validateTopology
+
+		Les caractéristiques absentes d'un lien (délai, débit, file, taux d'erreur) sont celles des
+		options -latency, -bandwidth, -queue, -loss, -corrupt et -duplicate. Une valeur 0 écrite dans
+		le fichier est gardée : un lien peut être sans pertes ou de débit illimité malgré les options.
+
+		Retourne :
+			- Un objet Graph dont les nœuds sont dans l'ordre du fichier
+	*/
+	nodes := make([]*Node, len(topo.Routers))
+	byName := make(map[string]*Node, len(topo.Routers))
+	for i, name := range topo.Routers {
+		nodes[i] = &Node{Name: name, Channel: make(chan Message)}
+		byName[name] = nodes[i]
+	}
+	for _, link := range topo.Links {
+		weight := link.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		nodeA := byName[link.From]
+		nodeB := byName[link.To]
+		edgeA := newEdge(nodeB, weight)
+		edgeB := newEdge(nodeA, weight)
+		for _, edge := range []*Edge{edgeA, edgeB} {
+			if link.Delay != "" {
+				edge.Delay, _ = time.ParseDuration(link.Delay)
+			}
+			if link.Bandwidth != nil {
+				edge.Bandwidth = *link.Bandwidth
+			}
+			if link.Queue != nil {
+				edge.QueueLimit = *link.Queue
+			}
+			if link.Loss != nil {
+				edge.Loss = *link.Loss
+			}
+			if link.Corrupt != nil {
+				edge.Corruption = *link.Corrupt
+			}
+			if link.Duplicate != nil {
+				edge.Duplication = *link.Duplicate
+			}
+		}
+		nodeA.Edges = append(nodeA.Edges, edgeA)
+		nodeB.Edges = append(nodeB.Edges, edgeB)
+	}
+	return Graph{Nodes: nodes}
+}
+
+func graphToTopology(g *Graph, maxInterfaces int, seed int64) TopologyFile {
+	/*
+		graphToTopology décrit un graphe au format des fichiers de topologie, pour l'écrire et le
+		recharger ensuite avec -topology.
+
+		Paramètres :
+			- g : le graphe à décrire
+			- maxInterfaces : le nombre maximal d'interfaces par routeur
+			- seed : la graine aléatoire du trafic enregistrée dans le fichier
+
+		Retourne :
+			- La topologie, avec chaque lien une seule fois et les routeurs dans l'ordre du graphe
+	*/
+	topo := TopologyFile{MaxInterfaces: maxInterfaces, Seed: seed, Routers: make([]string, len(g.Nodes))}
+	position := make(map[*Node]int, len(g.Nodes)) //Node.Index n'est à jour qu'une fois le routage lancé
+	for i, node := range g.Nodes {
+		position[node] = i
+	}
+	for i, node := range g.Nodes {
+		topo.Routers[i] = node.Name
+		for _, edge := range node.Edges {
+			if position[edge.To] > i {
+				link := TopologyLink{From: node.Name, To: edge.To.Name, Weight: edge.Weight}
+				// Seules les caractéristiques différentes des options sont écrites
+				if edge.Delay != *latencyFlag {
+					link.Delay = edge.Delay.String()
+				}
+				if bandwidth := edge.Bandwidth; bandwidth != *bandwidthFlag {
+					link.Bandwidth = &bandwidth
+				}
+				if queue := edge.QueueLimit; queue != *queueFlag {
+					link.Queue = &queue
+				}
+				if loss := edge.Loss; loss != *lossFlag {
+					link.Loss = &loss
+				}
+				if corrupt := edge.Corruption; corrupt != *corruptFlag {
+					link.Corrupt = &corrupt
+				}
+				if duplicate := edge.Duplication; duplicate != *duplicateFlag {
+					link.Duplicate = &duplicate
+				}
+				topo.Links = append(topo.Links, link)
+			}
+		}
+	}
+	return topo
+}
+
+func isProbability(p float64) bool {
+	/*
+		isProbability indique si un taux d'erreur est une probabilité valide.
+
+		Paramètres :
+			- p : le taux
+
+		Retourne :
+			- true si p est compris entre 0 et 1
+	*/
+	return p >= 0 && p <= 1
+}
+
+func isOptionalProbability(p *float64) bool {
+	/*
+		isOptionalProbability indique si un taux d'erreur optionnel d'un lien est valide.
+
+		Paramètres :
+			- p : le taux, nil s'il est absent du fichier
+
+		Retourne :
+			- true si le taux est absent ou compris entre 0 et 1
+	*/
+	return p == nil || isProbability(*p)
+}
+
+func maxDegree(g *Graph) int {
+	/*
+		maxDegree retourne le plus grand nombre de liens d'un nœud du graphe.
+
+		Paramètres :
+			- g : le graphe à parcourir
+
+		Retourne :
+			- Le degré maximal des nœuds du graphe
+	*/
+	max := 0
+	for _, node := range g.Nodes {
+		if len(node.Edges) > max {
+			max = len(node.Edges)
+		}
+	}
+	return max
+}
+
+//**** LECTURE YAML ****//
+
+func parseTopologyYAML(data string) (TopologyFile, error) {
+	/*
+		parseTopologyYAML lit le sous-ensemble de YAML utilisé pour décrire une topologie :
+
+			max_interfaces: 4
+			seed: 42
+			routers: [R1, R2]       # ou une liste "- R1" sur plusieurs lignes
+			links:
+			  - from: R1
+			    to: R2
+			    weight: 3          # ou "- {from: R1, to: R2, weight: 3}"
+
+		Paramètres :
+			- data : le contenu du fichier
+
+		Les commentaires (#) et les lignes vides sont ignorés. On évite ainsi une dépendance externe
+		pour un format aussi simple.
+
+		Retourne :
+			- La topologie lue
+			- Une erreur indiquant la ligne fautive si le contenu ne respecte pas ce format
+	*/
+	var topo TopologyFile
+	section := ""
+	var current *TopologyLink
+
+	for i, raw := range strings.Split(data, "\n") {
+		lineNumber := i + 1
+		line := stripYAMLComment(raw)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indented := line[0] == ' ' || line[0] == '\t'
+		line = strings.TrimSpace(line)
+
+		if !indented {
+			current = nil
+			key, value, ok := splitYAMLPair(line)
+			if !ok {
+				return topo, fmt.Errorf("ligne %d : clé attendue", lineNumber)
+			}
+			section = key
+			switch key {
+			case "max_interfaces":
+				n, err := strconv.Atoi(value)
+				if err != nil {
+					return topo, fmt.Errorf("ligne %d : max_interfaces doit être un entier", lineNumber)
+				}
+				topo.MaxInterfaces = n
+			case "seed":
+				n, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return topo, fmt.Errorf("ligne %d : seed doit être un entier", lineNumber)
+				}
+				topo.Seed = n
+			case "routers":
+				if value != "" {
+					topo.Routers = append(topo.Routers, splitYAMLFlowList(value)...)
+				}
+			case "links":
+				if value != "" && value != "[]" {
+					return topo, fmt.Errorf("ligne %d : les liens doivent être écrits sous forme de liste", lineNumber)
+				}
+			default:
+				return topo, fmt.Errorf("ligne %d : clé inconnue %q", lineNumber, key)
+			}
+			continue
+		}
+
+		switch section {
+		case "routers":
+			if !strings.HasPrefix(line, "-") {
+				return topo, fmt.Errorf("ligne %d : élément de liste attendu", lineNumber)
+			}
+			topo.Routers = append(topo.Routers, unquoteYAML(strings.TrimSpace(line[1:])))
+		case "links":
+			if strings.HasPrefix(line, "-") {
+				topo.Links = append(topo.Links, TopologyLink{})
+				current = &topo.Links[len(topo.Links)-1]
+				line = strings.TrimSpace(line[1:])
+				if strings.HasPrefix(line, "{") && strings.HasSuffix(line, "}") {
+					for _, pair := range splitYAMLFlowList(line[1 : len(line)-1]) {
+						if err := setLinkField(current, pair); err != nil {
+							return topo, fmt.Errorf("ligne %d : %w", lineNumber, err)
+						}
+					}
+					continue
+				}
+				if line == "" {
+					continue
+				}
+			}
+			if current == nil {
+				return topo, fmt.Errorf("ligne %d : élément de liste attendu", lineNumber)
+			}
+			if err := setLinkField(current, line); err != nil {
+				return topo, fmt.Errorf("ligne %d : %w", lineNumber, err)
+			}
+		default:
+			return topo, fmt.Errorf("ligne %d : indentation inattendue", lineNumber)
+		}
+	}
+	return topo, nil
+}
+
+func setLinkField(link *TopologyLink, pair string) error {
+	/*
+		setLinkField affecte un champ "clé: valeur" d'un lien YAML.
+
+		Paramètres :
+			- link : le lien en cours de lecture
+			- pair : la chaîne "clé: valeur"
+
+		Retourne :
+			- Une erreur si la clé est inconnue, si le poids, le débit ou la taille de file n'est pas un
+			  entier ou si un taux d'erreur n'est pas un nombre
+	*/
+	key, value, ok := splitYAMLPair(pair)
+	if !ok {
+		return fmt.Errorf("%q n'est pas de la forme clé: valeur", pair)
+	}
+	switch key {
+	case "from":
+		link.From = value
+	case "to":
+		link.To = value
+	case "weight":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("poids %q invalide", value)
+		}
+		link.Weight = n
+	case "delay":
+		link.Delay = value
+	case "bandwidth":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("débit %q invalide", value)
+		}
+		link.Bandwidth = &n
+	case "queue":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("taille de file %q invalide", value)
+		}
+		link.Queue = &n
+	case "loss", "corrupt", "duplicate":
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("taux %s %q invalide", key, value)
+		}
+		switch key {
+		case "loss":
+			link.Loss = &rate
+		case "corrupt":
+			link.Corrupt = &rate
+		default:
+			link.Duplicate = &rate
+		}
+	default:
+		return fmt.Errorf("champ de lien inconnu %q", key)
+	}
+	return nil
+}
+
+func splitYAMLPair(s string) (string, string, bool) {
+	key, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return "", "", false
+	}
+	return strings.TrimSpace(key), unquoteYAML(strings.TrimSpace(value)), true
+}
+
+func splitYAMLFlowList(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "[")
+	s = strings.TrimSuffix(s, "]")
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, unquoteYAML(item))
+		}
+	}
+	return items
+}
+
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func stripYAMLComment(line string) string {
+	line = strings.TrimRight(line, " \t\r")
+	if i := strings.Index(line, "#"); i >= 0 && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+		line = strings.TrimRight(line[:i], " \t")
+	}
+	return line
+}
+
+func findNode(g *Graph, name string) *Node {
+	/*
+		findNode cherche un nœud du graphe à partir de son nom.
+
+		Paramètres :
+			- g : le graphe à parcourir
+			- name : le nom du routeur (par exemple "R3")
+
+		Retourne :
+			- Le nœud portant ce nom, ou nil s'il n'existe pas
+	*/
+	for _, node := range g.Nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTopologyYAML(t *testing.T) {
+	/*
+		TestParseTopologyYAML lit le sous-ensemble de YAML des topologies : listes en bloc et en ligne,
+		commentaires, caractéristiques des liens, et refuse les contenus hors de ce format en indiquant
+		la ligne fautive.
+	*/
+	valid := `# commentaire
+max_interfaces: 3
+seed: 42
+routers: [R1, "R2"]
+routers:
+  - R3
+links:
+  - from: R1
+    to: R2   # poids absent
+  - {from: R2, to: R3, weight: 4, delay: 5ms, loss: 0, bandwidth: 0}
+`
+	topo, err := parseTopologyYAML(valid)
+	if err != nil {
+		t.Fatalf("topologie valide refusée : %v", err)
+	}
+	if topo.MaxInterfaces != 3 || topo.Seed != 42 {
+		t.Errorf("max_interfaces = %d, seed = %d ; attendu 3 et 42", topo.MaxInterfaces, topo.Seed)
+	}
+	if strings.Join(topo.Routers, " ") != "R1 R2 R3" {
+		t.Errorf("routeurs = %v ; attendu [R1 R2 R3]", topo.Routers)
+	}
+	if len(topo.Links) != 2 {
+		t.Fatalf("%d liens lus ; attendu 2", len(topo.Links))
+	}
+	first, second := topo.Links[0], topo.Links[1]
+	if first.From != "R1" || first.To != "R2" || first.Weight != 0 || first.Loss != nil {
+		t.Errorf("premier lien = %+v", first)
+	}
+	if second.Weight != 4 || second.Delay != "5ms" {
+		t.Errorf("second lien = %+v", second)
+	}
+	if second.Loss == nil || *second.Loss != 0 || second.Bandwidth == nil || *second.Bandwidth != 0 {
+		t.Errorf("les valeurs 0 explicites du second lien doivent être gardées : %+v", second)
+	}
+
+	invalid := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"clé inconnue", "routers: [R1, R2]\nnodes: 3\n", "ligne 2 : clé inconnue"},
+		{"clé absente", "routers: [R1, R2]\nR3\n", "ligne 2 : clé attendue"},
+		{"max_interfaces non entier", "max_interfaces: beaucoup\n", "ligne 1 : max_interfaces"},
+		{"seed non entière", "seed: 1.5\n", "ligne 1 : seed"},
+		{"liens en ligne", "links: R1-R2\n", "ligne 1 : les liens"},
+		{"routeur sans tiret", "routers:\n  R1\n", "ligne 2 : élément de liste attendu"},
+		{"champ avant le tiret", "links:\n  from: R1\n", "ligne 2 : élément de liste attendu"},
+		{"poids non entier", "links:\n  - {from: R1, to: R2, weight: lourd}\n", "ligne 2 : poids"},
+		{"débit non entier", "links:\n  - {from: R1, to: R2, bandwidth: rapide}\n", "ligne 2 : débit"},
+		{"champ de lien inconnu", "links:\n  - from: R1\n    color: red\n", "ligne 3 : champ de lien inconnu"},
+		{"taux non numérique", "links:\n  - {from: R1, to: R2, loss: peu}\n", "ligne 2 : taux loss"},
+		{"indentation inattendue", "seed: 1\n  routers: [R1]\n", "ligne 2 : indentation inattendue"},
+	}
+	for _, test := range invalid {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseTopologyYAML(test.data)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("erreur = %v ; attendu une erreur contenant %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateTopology(t *testing.T) {
+	/*
+		TestValidateTopology vérifie chacune des règles de validateTopology sur une petite topologie.
+	*/
+	rate := func(p float64) *float64 { return &p }
+	negative := int64(-1)
+	triangle := []TopologyLink{{From: "R1", To: "R2"}, {From: "R2", To: "R3"}, {From: "R3", To: "R1"}}
+	withLink := func(link TopologyLink) []TopologyLink {
+		return append(append([]TopologyLink(nil), triangle...), link)
+	}
+
+	tests := []struct {
+		name string
+		topo TopologyFile
+		want string //vide si la topologie est valide
+	}{
+		{"valide", TopologyFile{Routers: []string{"R1", "R2", "R3"}, Links: triangle}, ""},
+		{"taux nuls explicites", TopologyFile{Routers: []string{"R1", "R2"}, Links: []TopologyLink{{From: "R1", To: "R2", Loss: rate(0)}}}, ""},
+		{"un seul routeur", TopologyFile{Routers: []string{"R1"}}, "au moins deux routeurs"},
+		{"routeur sans nom", TopologyFile{Routers: []string{"R1", "", "R2"}, Links: []TopologyLink{{From: "R1", To: "R2"}}}, "sans nom"},
+		{"routeur en double", TopologyFile{Routers: []string{"R1", "R2", "R1"}, Links: []TopologyLink{{From: "R1", To: "R2"}}}, "défini plusieurs fois"},
+		{"routeur inconnu", TopologyFile{Routers: []string{"R1", "R2", "R3"}, Links: withLink(TopologyLink{From: "R1", To: "R9"})}, "routeur inconnu \"R9\""},
+		{"arête boucle", TopologyFile{Routers: []string{"R1", "R2", "R3"}, Links: withLink(TopologyLink{From: "R2", To: "R2"})}, "arête boucle"},
+		{"lien en double", TopologyFile{Routers: []string{"R1", "R2", "R3"}, Links: withLink(TopologyLink{From: "R2", To: "R1"})}, "en double"},
+		{"poids négatif", TopologyFile{Routers: []string{"R1", "R2", "R3"}, Links: []TopologyLink{{From: "R1", To: "R2", Weight: -3}, {From: "R2", To: "R3"}}}, "négatif"},
+		{"délai invalide", TopologyFile{Routers: []string{"R1", "R2"}, Links: []TopologyLink{{From: "R1", To: "R2", Delay: "vite"}}}, "délai"},
+		{"débit négatif", TopologyFile{Routers: []string{"R1", "R2"}, Links: []TopologyLink{{From: "R1", To: "R2", Bandwidth: &negative}}}, "débit ou taille de file"},
+		{"taux hors de [0, 1]", TopologyFile{Routers: []string{"R1", "R2"}, Links: []TopologyLink{{From: "R1", To: "R2", Duplicate: rate(1.5)}}}, "taux d'erreur"},
+		{"trop de liens", TopologyFile{MaxInterfaces: 1, Routers: []string{"R1", "R2", "R3"}, Links: triangle}, "plus que max_interfaces"},
+		{"non connexe", TopologyFile{Routers: []string{"R1", "R2", "R3", "R4"}, Links: []TopologyLink{{From: "R1", To: "R2"}, {From: "R3", To: "R4"}}}, "non connexe"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateTopology(test.topo)
+			switch {
+			case test.want == "" && err != nil:
+				t.Errorf("topologie valide refusée : %v", err)
+			case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+				t.Errorf("erreur = %v ; attendu une erreur contenant %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadTopologyFormats(t *testing.T) {
+	/*
+		TestLoadTopologyFormats charge les deux versions, JSON et YAML, de la topologie du laboratoire
+		et vérifie qu'elles donnent le même graphe.
+	*/
+	jsonGraph, _, err := loadTopology("topologies/lab.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlGraph, _, err := loadTopology("topologies/lab.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := describeLinks(&yamlGraph), describeLinks(&jsonGraph); got != want {
+		t.Errorf("liens YAML :\n%s\nliens JSON :\n%s", got, want)
+	}
+	if _, _, err := loadTopology("topologies/lab.txt"); err == nil {
+		t.Error("une extension inconnue doit être refusée")
+	}
+}
+
+func TestTopologyRoundTrip(t *testing.T) {
+	/*
+		TestTopologyRoundTrip écrit un graphe au format JSON avec graphToTopology puis le relit : les
+		caractéristiques des liens, y compris un taux de perte nul quand -loss ne l'est pas, doivent
+		être retrouvées.
+	*/
+	defer func(loss float64) { *lossFlag = loss }(*lossFlag)
+	*lossFlag = 0.3
+
+	zero := 0.0
+	topo := TopologyFile{
+		Routers: []string{"R1", "R2", "R3"},
+		Links: []TopologyLink{
+			{From: "R1", To: "R2", Weight: 2, Loss: &zero},
+			{From: "R2", To: "R3", Weight: 5, Delay: "20ms"},
+		},
+	}
+	graph := buildTopology(topo)
+	if edge := findEdge(graph.Nodes[0], graph.Nodes[1]); edge.Loss != 0 {
+		t.Errorf("perte du lien R1 - R2 = %v ; attendu 0 malgré -loss", edge.Loss)
+	}
+	if edge := findEdge(graph.Nodes[1], graph.Nodes[2]); edge.Loss != 0.3 || edge.Delay != 20*time.Millisecond {
+		t.Errorf("lien R2 - R3 : perte %v, délai %v ; attendu 0.3 et 20ms", edge.Loss, edge.Delay)
+	}
+
+	data, err := json.Marshal(graphToTopology(&graph, 2, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reread TopologyFile
+	if err := json.Unmarshal(data, &reread); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateTopology(reread); err != nil {
+		t.Fatalf("topologie écrite invalide : %v", err)
+	}
+	if reread.Seed != 7 || reread.MaxInterfaces != 2 {
+		t.Errorf("seed = %d, max_interfaces = %d ; attendu 7 et 2", reread.Seed, reread.MaxInterfaces)
+	}
+	rebuilt := buildTopology(reread)
+	if got, want := describeLinks(&rebuilt), describeLinks(&graph); got != want {
+		t.Errorf("graphe relu :\n%s\nattendu :\n%s", got, want)
+	}
+}
+
+func describeLinks(g *Graph) string {
+	/*
+		describeLinks décrit les liens d'un graphe, une ligne par lien et par sens, pour comparer deux graphes.
+
+		Paramètres :
+			- g : le graphe
+
+		Retourne :
+			- La description des liens, dans l'ordre des nœuds
+	*/
+	var lines []string
+	for _, node := range g.Nodes {
+		for _, edge := range node.Edges {
+			lines = append(lines, fmt.Sprintf("%s -> %s poids %d délai %v perte %v", node.Name, edge.To.Name, edge.Weight, edge.Delay, edge.Loss))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
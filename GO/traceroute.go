@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+//**** TRACEROUTE ****//
+
+func traceroute(g *Graph, nodeSrc *Node, nodeDst *Node) {
+	/*
+		traceroute envoie un Hello de nodeSrc vers nodeDst et affiche le chemin qu'il a réellement suivi,
+		saut par saut, avec le poids de chaque lien, le coût cumulé et le temps écoulé depuis le départ.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- nodeSrc : le routeur source
+			- nodeDst : le routeur destination
+
+		Le chemin suivi est ensuite comparé au plus court chemin calculé par Dijkstra sur le graphe
+		actuel : avec un protocole distribué qui n'a pas encore convergé, les deux peuvent différer.
+		Si le Hello n'est pas acquitté, la cause de l'échec (message expiré, destination injoignable,
+		délai dépassé) est affichée à la place du chemin.
+
+		La fonction ne retourne rien.
+	*/
+	request := newHelloRequest(nodeSrc, nodeDst)
+	if missing := helloRound([]*helloRequest{request}, *helloTimeout); len(missing) > 0 {
+		fmt.Printf("\nTraceroute de %s vers %s impossible : %s.\n", nodeSrc.Name, nodeDst.Name, request.Failure)
+		return
+	}
+
+	fmt.Printf("\nTraceroute de %s vers %s :\n", nodeSrc.Name, nodeDst.Name)
+	start := request.Path[0].At
+	cost := 0
+	actual := make([]*Node, len(request.Path))
+	for i, hop := range request.Path {
+		cost += hop.Weight
+		actual[i] = hop.Node
+		fmt.Printf("  %2d  %-6s poids %-4d coût cumulé %-6d +%v\n", i, hop.Node.Name, hop.Weight, cost, hop.At.Sub(start).Round(time.Microsecond))
+	}
+	fmt.Printf("Chemin suivi : %s (coût %d)\n", formatPath(actual), cost)
+
+	predicted, predictedCost := predictedPath(g, nodeSrc, nodeDst)
+	if predicted == nil {
+		fmt.Println("Dijkstra ne trouve aucun chemin sur le graphe actuel.")
+		return
+	}
+	fmt.Printf("Chemin prévu par Dijkstra : %s (coût %d)\n", formatPath(predicted), predictedCost)
+	switch {
+	case samePath(actual, predicted):
+		fmt.Println("Le chemin suivi est le chemin prévu.")
+	case cost == predictedCost:
+		fmt.Println("Le chemin suivi diffère du chemin prévu mais a le même coût.")
+	default:
+		fmt.Printf("Le chemin suivi diffère du chemin prévu (surcoût de %d).\n", cost-predictedCost)
+	}
+}
+
+func predictedPath(g *Graph, nodeSrc *Node, nodeDst *Node) ([]*Node, int) {
+	/*
+		predictedPath calcule le plus court chemin entre deux nœuds avec Dijkstra sur le graphe actuel,
+		indépendamment des tables de routage.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+			- nodeSrc : le nœud source
+			- nodeDst : le nœud destination
+
+		Retourne :
+			- Les nœuds du chemin, de la source à la destination incluses, nil si nodeDst est injoignable
+			- Le coût du chemin
+	*/
+	adj := buildAdjacency(g)
+	scratch := scratchPool.Get().(*dijkstraScratch)
+	defer scratchPool.Put(scratch)
+	shortestPaths(adj, nodeSrc.Index, scratch)
+
+	dst := nodeDst.Index
+	if scratch.Dist[dst] == infiniteDistance {
+		return nil, infiniteDistance
+	}
+	path := make([]*Node, scratch.Hops[dst]+1)
+	for i, current := len(path)-1, dst; i >= 0; i-- {
+		path[i] = g.Nodes[current]
+		current = scratch.Parent[current]
+	}
+	return path, scratch.Dist[dst]
+}
+
+func samePath(a []*Node, b []*Node) bool {
+	/*
+		samePath indique si deux chemins passent par les mêmes nœuds dans le même ordre.
+
+		Paramètres :
+			- a, b : les chemins à comparer
+
+		Retourne :
+			- true si les chemins sont identiques, false sinon
+	*/
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
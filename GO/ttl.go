@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+//**** DURÉE DE VIE DES MESSAGES ET DÉTECTION DES BOUCLES ****//
+
+// Notification envoyée à la source d'un message expiré en transit (MessageTimeExceeded)
+type TimeExceeded struct {
+	Original    MessageType //type du message expiré
+	Destination *Node       //destination du message expiré
+	Router      *Node       //routeur où le TTL du message est arrivé à 0
+	Route       []Hop       //route suivie par le message expiré
+	Loop        []*Node     //boucle de routage détectée sur cette route, nil sinon
+	Request     *helloRequest
+}
+
+// Compteurs globaux //
+var expiredMessages atomic.Int64 //messages détruits en transit parce que leur TTL est arrivé à 0
+var detectedLoops atomic.Int64   //messages dont la route est passée deux fois par le même routeur
+
+// Enregistrement du traitement des notifications
+func init() {
+	registerHandler(MessageTimeExceeded, "expired in transit", func(g *Graph, node *Node, message Message) {
+		async(func() { routing(node, message) })
+	})
+}
+
+func findLoop(route []Hop, node *Node) []*Node {
+	/*
+		findLoop cherche si node apparaît déjà sur la route d'un message, ce qui signifie que les tables
+		de routage des routeurs traversés forment une boucle.
+
+		Paramètres :
+			- route : la route suivie par le message jusqu'ici
+			- node : le routeur où le message vient d'arriver
+
+		Retourne :
+			- Les routeurs de la boucle, du premier passage par node jusqu'au retour sur node, ou nil
+			  s'il n'y a pas de boucle
+	*/
+	for i, hop := range route {
+		if hop.Node == node {
+			loop := make([]*Node, 0, len(route)-i+1)
+			for _, looped := range route[i:] {
+				loop = append(loop, looped.Node)
+			}
+			return append(loop, node)
+		}
+	}
+	return nil
+}
+
+func reportLoop(node *Node, message Message, loop []*Node) {
+	/*
+		reportLoop affiche la boucle de routage dans laquelle un message est pris.
+
+		Paramètres :
+			- node : le routeur qui a détecté la boucle
+			- message : le message pris dans la boucle
+			- loop : les routeurs de la boucle
+
+		La fonction ne retourne rien.
+	*/
+	detectedLoops.Add(1)
+	fmt.Printf("Boucle de routage détectée par %s pour le message %v de %s vers %s : %s\n",
+		node.Name, message.Type, message.Source.Name, message.Destination.Name, formatPath(loop))
+}
+
+func expireInTransit(node *Node, message Message) {
+	/*
+		expireInTransit détruit un message dont le TTL est arrivé à 0 et en avertit sa source par une
+		notification MessageTimeExceeded, routée comme un message normal.
+
+		Paramètres :
+			- node : le routeur où le message expire
+			- message : le message expiré
+
+		Une notification qui expire elle-même est seulement détruite, pour ne pas créer de notification
+		de notification.
+
+		La fonction ne retourne rien.
+	*/
+	expiredMessages.Add(1)
+	fmt.Printf("%s : message %v de %s vers %s expiré en transit après %d sauts.\n",
+		node.Name, message.Type, message.Source.Name, message.Destination.Name, len(message.Route)-1)
+	if message.Type == MessageTimeExceeded {
+		return
+	}
+
+	request, _ := message.Payload.(*helloRequest)
+	notification := Message{
+		Source:      node,
+		Destination: message.Source,
+		Type:        MessageTimeExceeded,
+		TTL:         *ttlFlag,
+		Route:       []Hop{{Node: node, At: clockNow()}},
+		Flow:        message.Flow,
+		Payload: &TimeExceeded{Original: message.Type, Destination: message.Destination, Router: node,
+			Route: message.Route, Loop: message.Loop, Request: request},
+	}
+	if node == message.Source {
+		handleTimeExceeded(node, notification)
+		return
+	}
+	forward(node, notification)
+}
+
+func handleTimeExceeded(node *Node, received Message) {
+	/*
+		handleTimeExceeded traite, à la source d'un message, la notification de son expiration en transit :
+		la cause est affichée et la demande Hello correspondante échoue sans attendre le délai.
+
+		Paramètres :
+			- node : la source du message expiré
+			- received : la notification
+
+		La fonction ne retourne rien.
+	*/
+	expired := received.Payload.(*TimeExceeded)
+	fmt.Printf("%s a reçu une notification : son message %v vers %s a expiré en transit à %s.\nRoute : %s\n",
+		node.Name, expired.Original, expired.Destination.Name, expired.Router.Name, afficherRoute(expired.Route))
+	reason := "expiré en transit à " + expired.Router.Name
+	if expired.Loop != nil {
+		fmt.Printf("Boucle : %s\n", formatPath(expired.Loop))
+		reason += ", boucle " + formatPath(expired.Loop)
+	}
+	if expired.Request != nil {
+		expired.Request.fail(reason)
+	}
+}
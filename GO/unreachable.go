@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+//**** DESTINATIONS INJOIGNABLES ET PARTITIONS DU RÉSEAU ****//
+
+// Notification envoyée à la source d'un message qu'un routeur n'a pas pu transmettre faute de route (MessageDestinationUnreachable)
+type DestinationUnreachable struct {
+	Original    MessageType //type du message détruit
+	Destination *Node       //destination du message détruit
+	Router      *Node       //routeur qui n'avait pas de route vers la destination
+	Route       []Hop       //route suivie par le message jusqu'à ce routeur
+	Request     *helloRequest
+}
+
+// Compteurs globaux //
+var unroutableMessages atomic.Int64 //messages détruits parce qu'un routeur n'avait pas de route vers leur destination
+var unreachableNotices atomic.Int64 //notifications "destination unreachable" envoyées
+var partitionCount = 1              //nombre de parties du réseau au dernier changement de topologie (voir reportPartitions)
+var partitionLimit = 10             //nombre maximal de routeurs affichés par partie
+
+// Enregistrement du traitement des notifications
+func init() {
+	registerHandler(MessageDestinationUnreachable, "destination unreachable", func(g *Graph, node *Node, message Message) {
+		async(func() { routing(node, message) })
+	})
+}
+
+func unreachableInTransit(node *Node, message Message) {
+	/*
+		unreachableInTransit détruit un message que node ne sait pas où transmettre (réseau coupé en
+		plusieurs parties, protocole pas encore convergé, annonces perdues) et en avertit sa source par
+		une notification MessageDestinationUnreachable, routée comme un message normal.
+
+		Paramètres :
+			- node : le routeur qui n'a pas de route vers la destination du message
+			- message : le message détruit
+
+		Si node est lui-même la source du message, la notification est traitée sur place. Une
+		notification qui ne peut pas être transmise est seulement détruite, pour ne pas créer de
+		notification de notification.
+
+		La fonction ne retourne rien.
+	*/
+	unroutableMessages.Add(1)
+	if message.Type == MessageDestinationUnreachable || message.Type == MessageTimeExceeded {
+		return
+	}
+
+	request, _ := message.Payload.(*helloRequest)
+	notification := Message{
+		Source:      node,
+		Destination: message.Source,
+		Type:        MessageDestinationUnreachable,
+		TTL:         *ttlFlag,
+		Route:       []Hop{{Node: node, At: clockNow()}},
+		Flow:        message.Flow,
+		Payload: &DestinationUnreachable{Original: message.Type, Destination: message.Destination, Router: node,
+			Route: message.Route, Request: request},
+	}
+	unreachableNotices.Add(1)
+	if node == message.Source {
+		handleDestinationUnreachable(node, notification)
+		return
+	}
+	forward(node, notification)
+}
+
+func handleDestinationUnreachable(node *Node, received Message) {
+	/*
+		handleDestinationUnreachable traite, à la source d'un message, la notification qu'il n'a pas pu
+		être transmis : la cause est affichée et la demande Hello correspondante échoue sans attendre
+		le délai.
+
+		Paramètres :
+			- node : la source du message détruit
+			- received : la notification
+
+		La fonction ne retourne rien.
+	*/
+	unreachable := received.Payload.(*DestinationUnreachable)
+	fmt.Printf("%s a reçu une notification : son message %v vers %s est injoignable depuis %s.\nRoute : %s\n",
+		node.Name, unreachable.Original, unreachable.Destination.Name, unreachable.Router.Name, afficherRoute(unreachable.Route))
+	if unreachable.Request != nil {
+		unreachable.Request.fail("destination injoignable depuis " + unreachable.Router.Name)
+	}
+}
+
+func connectedComponents(g *Graph) [][]*Node {
+	/*
+		connectedComponents cherche les parties connexes du réseau, par un parcours en largeur des
+		liens actuels.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		Les routeurs en panne, qui n'ont plus de liens, ne sont comptés dans aucune partie.
+
+		Retourne :
+			- Les parties, chacune dans l'ordre de parcours depuis son premier routeur dans l'ordre
+			  du graphe, et les parties dans l'ordre de leur premier routeur
+	*/
+	visited := make([]bool, len(g.Nodes))
+	var components [][]*Node
+	for _, start := range g.Nodes {
+		if visited[start.Index] || start.Failed() {
+			continue
+		}
+		visited[start.Index] = true
+		component := []*Node{start}
+		for i := 0; i < len(component); i++ {
+			node := component[i]
+			node.edgesMu.RLock()
+			for _, edge := range node.Edges {
+				if !visited[edge.To.Index] {
+					visited[edge.To.Index] = true
+					component = append(component, edge.To)
+				}
+			}
+			node.edgesMu.RUnlock()
+		}
+		components = append(components, component)
+	}
+	return components
+}
+
+func formatComponent(component []*Node) string {
+	/*
+		formatComponent écrit une partie du réseau sous la forme "[R1 R2 R3]", en n'affichant que ses
+		premiers routeurs si elle est grande.
+
+		Paramètres :
+			- component : les routeurs de la partie
+
+		Retourne :
+			- La description de la partie
+	*/
+	if len(component) <= partitionLimit {
+		return "[" + formatPath(component) + "]"
+	}
+	return fmt.Sprintf("[%s ... et %d autres]", formatPath(component[:partitionLimit]), len(component)-partitionLimit)
+}
+
+func reportPartitions(g *Graph) {
+	/*
+		reportPartitions affiche les parties du réseau après un changement de topologie, s'il est coupé
+		en plusieurs parties ou s'il vient de redevenir connexe. Les routeurs de parties différentes ne
+		peuvent plus communiquer : leurs routes sont injoignables et leurs Hello échouent.
+
+		Paramètres :
+			- g : le graphe global contenant l'ensemble des nœuds
+
+		La fonction ne retourne rien.
+	*/
+	components := connectedComponents(g)
+	previous := partitionCount
+	partitionCount = len(components)
+	if partitionCount <= 1 {
+		if previous > 1 {
+			fmt.Printf("Le réseau est de nouveau connexe.\n")
+		}
+		return
+	}
+	parts := make([]string, len(components))
+	for i, component := range components {
+		parts[i] = formatComponent(component)
+	}
+	fmt.Printf("Le réseau est coupé en %d parties : %s", partitionCount, strings.Join(parts, " "))
+	if failed := failedRouters(g); len(failed) > 0 {
+		fmt.Printf(" (en panne : %s)", formatPath(failed))
+	}
+	fmt.Println()
+}
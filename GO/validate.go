@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+)
+
+//**** VÉRIFICATION DES INVARIANTS DU GRAPHE ****//
+
+// Règle du graphe non respectée
+type ViolationKind int
+
+const (
+	ViolationMissingReverse ViolationKind = iota + 1 //lien A -> B sans lien B -> A
+	ViolationWeightMismatch                          //liens A -> B et B -> A de poids différents
+	ViolationDuplicateEdge                           //plusieurs liens A -> B
+	ViolationSelfLoop                                //lien A -> A
+	ViolationTooManyEdges                            //plus de liens que d'interfaces
+	ViolationUnknownNode                             //lien vers un nœud absent du graphe
+	ViolationBadWeight                               //poids nul ou négatif
+)
+
+// Violation d'une règle du graphe, trouvée par Validate
+type Violation struct {
+	Kind   ViolationKind
+	Node   *Node  //nœud d'où part le lien fautif, ou nœud qui a trop de liens
+	Other  *Node  //autre extrémité du lien, nil pour ViolationTooManyEdges
+	Detail string //description complète de la violation
+}
+
+func (kind ViolationKind) String() string {
+	/*
+		String donne le nom court d'une règle du graphe.
+
+		Retourne :
+			- Le nom de la règle, ou "inconnue (n)"
+	*/
+	switch kind {
+	case ViolationMissingReverse:
+		return "lien retour manquant"
+	case ViolationWeightMismatch:
+		return "poids asymétrique"
+	case ViolationDuplicateEdge:
+		return "lien en double"
+	case ViolationSelfLoop:
+		return "arête boucle"
+	case ViolationTooManyEdges:
+		return "trop de liens"
+	case ViolationUnknownNode:
+		return "nœud inconnu"
+	case ViolationBadWeight:
+		return "poids invalide"
+	}
+	return fmt.Sprintf("inconnue (%d)", int(kind))
+}
+
+func (v Violation) String() string {
+	/*
+		String décrit la violation.
+
+		Retourne :
+			- La description de la violation
+	*/
+	return v.Detail
+}
+
+func Validate(g *Graph) []Violation {
+	/*
+		Validate vérifie les règles que tout le simulateur suppose vraies sur le graphe :
+			- chaque lien A -> B a un lien B -> A de même poids ;
+			- il n'y a pas deux liens A -> B ni de lien A -> A ;
+			- aucun routeur n'a plus de maxEdges liens ;
+			- tous les liens mènent à un nœud du graphe et ont un poids positif.
+
+		Paramètres :
+			- g : le graphe à vérifier
+
+		Un lien asymétrique est signalé une seule fois, par le nœud d'où il part. Les liens sont lus
+		sous le verrou de chaque nœud : Validate peut être appelée pendant la simulation.
+
+		Retourne :
+			- Les violations, dans l'ordre des nœuds du graphe, nil si le graphe est valide
+	*/
+	position := make(map[*Node]int, len(g.Nodes)) //Node.Index n'est à jour qu'une fois le routage lancé
+	for i, node := range g.Nodes {
+		position[node] = i
+	}
+	var violations []Violation
+	add := func(kind ViolationKind, node *Node, other *Node, format string, args ...interface{}) {
+		violations = append(violations, Violation{Kind: kind, Node: node, Other: other, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	for _, node := range g.Nodes {
+		node.edgesMu.RLock()
+		edges := append([]*Edge(nil), node.Edges...)
+		node.edgesMu.RUnlock()
+
+		if maxEdges > 0 && len(edges) > maxEdges {
+			add(ViolationTooManyEdges, node, nil, "%s a %d liens pour %d interfaces", node.Name, len(edges), maxEdges)
+		}
+		seen := make(map[*Node]bool, len(edges))
+		for _, edge := range edges {
+			other := edge.To
+			if _, ok := position[other]; !ok {
+				add(ViolationUnknownNode, node, other, "%s a un lien vers un nœud absent du graphe", node.Name)
+				continue
+			}
+			switch {
+			case other == node:
+				add(ViolationSelfLoop, node, other, "%s a un lien vers lui-même", node.Name)
+				continue
+			case seen[other]:
+				add(ViolationDuplicateEdge, node, other, "%s a plusieurs liens vers %s", node.Name, other.Name)
+				continue
+			}
+			seen[other] = true
+			if edge.Weight <= 0 {
+				add(ViolationBadWeight, node, other, "le lien %s -> %s a un poids de %d", node.Name, other.Name, edge.Weight)
+			}
+			reverse := findEdge(other, node)
+			switch {
+			case reverse == nil:
+				add(ViolationMissingReverse, node, other, "le lien %s -> %s n'a pas de lien retour %s -> %s", node.Name, other.Name, other.Name, node.Name)
+			case reverse.Weight != edge.Weight && position[node] < position[other]:
+				add(ViolationWeightMismatch, node, other, "le lien %s - %s a le poids %d dans un sens et %d dans l'autre",
+					node.Name, other.Name, edge.Weight, reverse.Weight)
+			}
+		}
+	}
+	return violations
+}
+
+func reportViolations(g *Graph, when string) bool {
+	/*
+		reportViolations vérifie le graphe avec Validate et affiche les violations trouvées.
+
+		Paramètres :
+			- g : le graphe à vérifier
+			- when : le moment de la vérification, pour l'affichage ("après la génération"...)
+
+		Rien n'est affiché si le graphe est valide.
+
+		Retourne :
+			- true si le graphe est valide
+	*/
+	violations := Validate(g)
+	if len(violations) == 0 {
+		return true
+	}
+	fmt.Printf("Graphe invalide %s : %d violations.\n", when, len(violations))
+	for _, violation := range violations {
+		fmt.Printf("  - %v : %s\n", violation.Kind, violation)
+	}
+	return false
+}
+
+func linkRefusal(nodeA *Node, nodeB *Node) string {
+	/*
+		linkRefusal indique si un lien peut être ajouté entre deux routeurs sans violer les règles
+		vérifiées par Validate.
+
+		Paramètres :
+			- nodeA : une extrémité du lien
+			- nodeB : l'autre extrémité
+
+		Retourne :
+			- La raison du refus, vide si le lien peut être ajouté
+	*/
+	switch {
+	case nodeA == nodeB:
+		return "un routeur ne peut pas être relié à lui-même"
+	case edgeExists(nodeA, nodeB):
+		return fmt.Sprintf("le lien %s - %s existe déjà", nodeA.Name, nodeB.Name)
+	case nodeA.Failed() || nodeB.Failed():
+		return fmt.Sprintf("%s ou %s est en panne", nodeA.Name, nodeB.Name)
+	case len(nodeA.Edges) >= maxEdges:
+		return fmt.Sprintf("%s n'a plus d'interface libre (%d liens)", nodeA.Name, len(nodeA.Edges))
+	case len(nodeB.Edges) >= maxEdges:
+		return fmt.Sprintf("%s n'a plus d'interface libre (%d liens)", nodeB.Name, len(nodeB.Edges))
+	}
+	return ""
+}
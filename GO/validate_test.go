@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	/*
+		TestValidate construit à la main un graphe qui viole chacune des règles de Validate, à partir
+		d'un triangle valide R1 - R2 - R3, et compare les violations trouvées à celles attendues. Un
+		lien de poids asymétrique n'est signalé qu'une fois, un lien en double ou de poids invalide
+		l'est dans chaque sens.
+	*/
+	defer func(edges int) { maxEdges = edges }(maxEdges)
+	stranger := &Node{Name: "R9"}
+	tests := []struct {
+		name       string
+		maxEdges   int
+		breakGraph func(nodes []*Node)
+		want       []string //règle, nœud et autre extrémité de chaque violation, nil si le graphe est valide
+	}{
+		{"graphe valide", 2, func([]*Node) {}, nil},
+		{"lien retour manquant", 3, func(nodes []*Node) {
+			nodes[0].Edges = append(nodes[0].Edges, &Edge{To: nodes[3], Weight: 1})
+		}, []string{"lien retour manquant R1 -> R4"}},
+		{"poids asymétrique", 2, func(nodes []*Node) {
+			findEdge(nodes[1], nodes[0]).Weight = 7
+		}, []string{"poids asymétrique R1 -> R2"}},
+		{"lien en double", 3, func(nodes []*Node) {
+			linkNodes(nodes[0], nodes[1], 1)
+		}, []string{"lien en double R1 -> R2", "lien en double R2 -> R1"}},
+		{"arête boucle", 3, func(nodes []*Node) {
+			nodes[2].Edges = append(nodes[2].Edges, &Edge{To: nodes[2], Weight: 1})
+		}, []string{"arête boucle R3 -> R3"}},
+		{"trop de liens", 2, func(nodes []*Node) {
+			linkNodes(nodes[0], nodes[3], 1)
+		}, []string{"trop de liens R1"}},
+		{"nœud inconnu", 3, func(nodes []*Node) {
+			nodes[0].Edges = append(nodes[0].Edges, &Edge{To: stranger, Weight: 1})
+		}, []string{"nœud inconnu R1 -> R9"}},
+		{"poids invalide", 3, func(nodes []*Node) {
+			linkNodes(nodes[1], nodes[3], 0)
+		}, []string{"poids invalide R2 -> R4", "poids invalide R4 -> R2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			graph := routersGraph(4, [][3]int{{0, 1, 1}, {1, 2, 1}, {2, 0, 1}})
+			test.breakGraph(graph.Nodes)
+			maxEdges = test.maxEdges
+
+			violations := Validate(&graph)
+			var got []string
+			for _, violation := range violations {
+				description := violation.Kind.String() + " " + violation.Node.Name
+				if violation.Other != nil {
+					description += " -> " + violation.Other.Name
+				}
+				got = append(got, description)
+				if violation.Detail == "" {
+					t.Errorf("violation %s sans description", description)
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("violations %q ; attendu %q", got, test.want)
+			}
+			if test.want == nil && violations != nil {
+				t.Errorf("Validate doit retourner nil pour un graphe valide, pas %#v", violations)
+			}
+		})
+	}
+}
+
+func TestLinkRefusalShapeInterfaces(t *testing.T) {
+	/*
+		TestLinkRefusalShapeInterfaces génère un anneau de 6 routeurs avec buildGraph et vérifie qu'un
+		lien R1 - R4 n'est accepté que si -i donne plus d'interfaces que les deux liens de l'anneau.
+	*/
+	defer func(shape string, nodes, interfaces int, seed int64) {
+		*shapeFlag, *nodesFlag, *interfacesFlag, *seedFlag = shape, nodes, interfaces, seed
+	}(*shapeFlag, *nodesFlag, *interfacesFlag, *seedFlag)
+	defer func(count, edges int, r *rand.Rand, faults *faultSource, seed int64) {
+		nodesCount, maxEdges, rng, faultRng, simulationSeed = count, edges, r, faults, seed
+	}(nodesCount, maxEdges, rng, faultRng, simulationSeed)
+	*shapeFlag, *nodesFlag, *seedFlag = "ring", 6, 1
+
+	for _, test := range []struct {
+		interfaces int
+		accepted   bool
+	}{{0, false}, {2, false}, {4, true}} {
+		*interfacesFlag = test.interfaces
+		graph, err := buildGraph(false)
+		if err != nil {
+			t.Fatalf("-i %d : %v", test.interfaces, err)
+		}
+		refusal := linkRefusal(graph.Nodes[0], graph.Nodes[3])
+		if (refusal == "") != test.accepted {
+			t.Errorf("-i %d : lien R1 - R4 refusé %q, attendu accepté %v", test.interfaces, refusal, test.accepted)
+		}
+	}
+}